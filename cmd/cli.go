@@ -31,7 +31,9 @@ var rootCmd = &cobra.Command{
 	},
 }
 
-func ExecuteCli() {
+// ExecuteCli runs the command line with the arguments left after the flags of the main program
+func ExecuteCli(args []string) {
+	rootCmd.SetArgs(args)
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal(err)
 	}
@@ -46,13 +48,20 @@ func init() {
 func main(env initializers.Env) {
 	log.Printf("Source: %s\n", env.MovieSourceFolder)
 	log.Printf("Destination: %s\n", env.MovieTargetFolder)
-	var mediaClient = pkg.NewMediaClient(env.TMDBApiKey)
+	mediaClient, err := initializers.InitMediaClient(env, false)
+	if err != nil {
+		log.Fatal(err)
+	}
 	db, err := initializers.ConnectToDB(env)
 	if err != nil {
 		log.Fatal(err)
 	}
-	var mediaRepository = repository.NewMediaRepository(db, env.IntroFilePath, env.Intro219FilePath)
-	var movieScanner = features.NewMovieScanner(env.MovieSourceFolder, env.MovieTargetFolder, mediaClient, mediaRepository, nil)
+	duplicatePolicy, err := pkg.ParseDuplicatePolicy(env.DuplicatePolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var mediaRepository = repository.NewMediaRepository(db, env.IntroFilePath, env.Intro219FilePath, duplicatePolicy)
+	var movieScanner = features.NewMovieScanner(env.MovieSourceFolder, env.MovieTargetFolder, mediaClient, mediaRepository, nil, initializers.InitTreeOptions(env), env.NotFoundRetry)
 	err = movieScanner.ScanMovies()
 	if err != nil {
 		log.Fatal(err)
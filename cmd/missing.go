@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/bingemate/media-indexer/initializers"
+	"github.com/bingemate/media-indexer/internal/features"
+	"github.com/bingemate/media-indexer/internal/repository"
+	"github.com/bingemate/media-indexer/pkg"
+	"github.com/spf13/cobra"
+	"log"
+	"strconv"
+)
+
+var missingCmd = &cobra.Command{
+	Use:   "missing <tv show id>",
+	Short: "List the missing episodes of a TV show",
+	Long:  "List the episodes of a TV show listed by TMDB that are not in the library, aired or not yet aired",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tvShowID, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatalf("Invalid TV show id %s", args[0])
+		}
+		env, err := initializers.LoadEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		missing(env, tvShowID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(missingCmd)
+}
+
+func missing(env initializers.Env, tvShowID int) {
+	mediaClient, err := initializers.InitMediaClient(env, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := initializers.ConnectToDB(env)
+	if err != nil {
+		log.Fatal(err)
+	}
+	duplicatePolicy, err := pkg.ParseDuplicatePolicy(env.DuplicatePolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var mediaRepository = repository.NewMediaRepository(db, env.IntroFilePath, env.Intro219FilePath, duplicatePolicy)
+	var missingEpisodesFinder = features.NewMissingEpisodesFinder(mediaClient, mediaRepository)
+	report, err := missingEpisodesFinder.FindMissingEpisodes(tvShowID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%s (%d)\n", report.TvShowName, report.TvShowID)
+	fmt.Printf("Aired but not in library (%d):\n", len(report.Aired))
+	for _, episode := range report.Aired {
+		fmt.Printf("  S%02dE%02d  %-10s  %s\n", episode.Season, episode.Episode, episode.AirDate, episode.Name)
+	}
+	fmt.Printf("Not yet aired (%d):\n", len(report.NotYetAired))
+	for _, episode := range report.NotYetAired {
+		fmt.Printf("  S%02dE%02d  %-10s  %s\n", episode.Season, episode.Episode, episode.AirDate, episode.Name)
+	}
+}
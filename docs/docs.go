@@ -102,6 +102,53 @@ const docTemplate = `{
                 }
             }
         },
+        "/movie/{id}/versions": {
+            "get": {
+                "description": "List the versions of a movie in the library, each stored in its own folder under \"movies/\" in the object storage",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Movie"
+                ],
+                "summary": "Get Movie Versions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "TMDB movie ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.movieVersionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/ping": {
             "get": {
                 "description": "Ping",
@@ -203,6 +250,53 @@ const docTemplate = `{
                 }
             }
         },
+        "/tv/{id}/missing": {
+            "get": {
+                "description": "List the episodes of a TV show listed by TMDB that are not in the library, aired or not yet aired",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TV"
+                ],
+                "summary": "Get Missing Episodes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "TMDB TV show ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.missingEpisodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/upload/movie": {
             "post": {
                 "description": "Upload movies from the configured folder",
@@ -318,6 +412,53 @@ const docTemplate = `{
                 }
             }
         },
+        "controllers.missingEpisodesResponse": {
+            "type": "object",
+            "properties": {
+                "aired": {
+                    "description": "Episodes already aired but not in the library",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/features.MissingEpisode"
+                    }
+                },
+                "notYetAired": {
+                    "description": "Episodes announced by TMDB but not aired yet",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/features.MissingEpisode"
+                    }
+                },
+                "tvShowId": {
+                    "type": "integer",
+                    "example": 1396
+                },
+                "tvShowName": {
+                    "type": "string",
+                    "example": "Breaking Bad"
+                }
+            }
+        },
+        "controllers.movieVersionsResponse": {
+            "type": "object",
+            "properties": {
+                "movieId": {
+                    "type": "integer",
+                    "example": 27205
+                },
+                "movieName": {
+                    "type": "string",
+                    "example": "Inception"
+                },
+                "versions": {
+                    "description": "Versions of the movie, the main one first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/features.MovieVersion"
+                    }
+                }
+            }
+        },
         "controllers.uploadResponse": {
             "type": "object",
             "properties": {
@@ -328,6 +469,62 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "features.MissingEpisode": {
+            "type": "object",
+            "properties": {
+                "airDate": {
+                    "type": "string",
+                    "example": "2008-01-20"
+                },
+                "episode": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Pilot"
+                },
+                "season": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "features.MovieVersion": {
+            "type": "object",
+            "properties": {
+                "edition": {
+                    "type": "string",
+                    "example": "Director's Cut"
+                },
+                "label": {
+                    "type": "string",
+                    "example": "Director's Cut 2160p"
+                },
+                "main": {
+                    "description": "Whether it is the media file of the movie itself",
+                    "type": "boolean",
+                    "example": false
+                },
+                "mediaFileId": {
+                    "type": "string",
+                    "example": "0b7c5c39-5d5f-4c8e-9a3c-1f0d1c2b3a4e"
+                },
+                "resolution": {
+                    "type": "string",
+                    "example": "2160p"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "BluRay"
+                },
+                "storageFolder": {
+                    "description": "Folder of the version under \"movies/\" in the object storage",
+                    "type": "string",
+                    "example": "27205-director-s-cut-2160p"
+                }
+            }
         }
     }
 }`
@@ -3,6 +3,7 @@ package initializers
 import (
 	"fmt"
 	"github.com/bingemate/media-go-pkg/repository"
+	indexerRepository "github.com/bingemate/media-indexer/internal/repository"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log"
@@ -25,6 +26,10 @@ func ConnectToDB(env Env) (*gorm.DB, error) {
 		if err != nil {
 			return nil, err
 		}
+		err = indexerRepository.Migrate(db)
+		if err != nil {
+			return nil, err
+		}
 		log.Println("Database synced")
 	}
 	return db, nil
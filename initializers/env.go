@@ -4,31 +4,51 @@ import (
 	"github.com/caarlos0/env/v8"
 	"github.com/joho/godotenv"
 	"os"
+	"time"
 )
 
 type Env struct {
-	Port              string `env:"PORT" envDefault:"8080"`
-	LogFile           string `env:"LOG_FILE" envDefault:"gin.log"`
-	IntroFilePath     string `env:"INTRO_FILE_PATH" envDefault:"app/assets/intro.mkv"`
-	Intro219FilePath  string `env:"INTRO_21_9_FILE_PATH" envDefault:"app/assets/intro_21-9.mp4"`
-	MovieSourceFolder string `env:"MOVIE_SOURCE_FOLDER" envDefault:"./"`
-	MovieTargetFolder string `env:"MOVIE_TARGET_FOLDER" envDefault:"./"`
-	TvSourceFolder    string `env:"TV_SOURCE_FOLDER" envDefault:"./"`
-	TvTargetFolder    string `env:"TV_TARGET_FOLDER" envDefault:"./"`
-	TMDBApiKey        string `env:"TMDB_API_KEY" envDefault:""`
-	DBSync            bool   `env:"DB_SYNC" envDefault:"false"`
-	DBHost            string `env:"DB_HOST" envDefault:"localhost"`
-	DBPort            string `env:"DB_PORT" envDefault:"5432"`
-	DBUser            string `env:"DB_USER" envDefault:"postgres"`
-	DBPassword        string `env:"DB_PASSWORD" envDefault:"postgres"`
-	DBName            string `env:"DB_NAME" envDefault:"postgres"`
-	RedisHost         string `env:"REDIS_HOST" envDefault:"localhost:6379"`
-	RedisPassword     string `env:"REDIS_PASSWORD" envDefault:""`
-	S3AccessKeyId     string `env:"S3_ACCESS_KEY_ID" envDefault:""`
-	S3SecretAccessKey string `env:"S3_SECRET_ACCESS_KEY" envDefault:""`
-	S3BucketName      string `env:"S3_BUCKET_NAME" envDefault:""`
-	S3Endpoint        string `env:"S3_ENDPOINT" envDefault:"https://s3.fr-par.scw.cloud"`
-	ScanCron          string `env:"SCAN_CRON" envDefault:"*/15 * * * *"`
+	Port              string        `env:"PORT" envDefault:"8080"`
+	LogFile           string        `env:"LOG_FILE" envDefault:"gin.log"`
+	IntroFilePath     string        `env:"INTRO_FILE_PATH" envDefault:"app/assets/intro.mkv"`
+	Intro219FilePath  string        `env:"INTRO_21_9_FILE_PATH" envDefault:"app/assets/intro_21-9.mp4"`
+	MovieSourceFolder string        `env:"MOVIE_SOURCE_FOLDER" envDefault:"./"`
+	MovieTargetFolder string        `env:"MOVIE_TARGET_FOLDER" envDefault:"./"`
+	TvSourceFolder    string        `env:"TV_SOURCE_FOLDER" envDefault:"./"`
+	TvTargetFolder    string        `env:"TV_TARGET_FOLDER" envDefault:"./"`
+	TVAnimeMode       string        `env:"TV_ANIME_MODE" envDefault:"auto"`
+	DuplicatePolicy   string        `env:"DUPLICATE_POLICY" envDefault:"replace"`
+	NotFoundRetry     time.Duration `env:"NOT_FOUND_RETRY" envDefault:"168h"`
+	MediaExtensions   []string      `env:"MEDIA_EXTENSIONS" envSeparator:"," envDefault:".mp4,.mkv,.avi,.m4v,.mov,.webm,.ts,.m2ts"`
+	MediaSniffing     bool          `env:"MEDIA_SNIFFING" envDefault:"false"`
+	IgnorePatterns    []string      `env:"IGNORE_PATTERNS" envSeparator:","`
+	SampleMaxSizeMB   int64         `env:"SAMPLE_MAX_SIZE_MB" envDefault:"50"`
+	SampleMaxDuration time.Duration `env:"SAMPLE_MAX_DURATION" envDefault:"2m"`
+	FileSettlePeriod  time.Duration `env:"FILE_SETTLE_PERIOD" envDefault:"1m"`
+	FollowSymlinks    bool          `env:"FOLLOW_SYMLINKS" envDefault:"false"`
+	WalkWorkers       int           `env:"WALK_WORKERS" envDefault:"4"`
+	TMDBApiKey        string        `env:"TMDB_API_KEY" envDefault:""`
+	TMDBRateLimit     float64       `env:"TMDB_RATE_LIMIT" envDefault:"2"`
+	TMDBRateBurst     int           `env:"TMDB_RATE_BURST" envDefault:"4"`
+	TMDBMaxRetries    int           `env:"TMDB_MAX_RETRIES" envDefault:"3"`
+	TMDBRetryBackoff  time.Duration `env:"TMDB_RETRY_BACKOFF" envDefault:"1s"`
+	MediaClientMode   string        `env:"MEDIA_CLIENT_MODE" envDefault:"tmdb"`
+	FixtureFolder     string        `env:"FIXTURE_FOLDER" envDefault:"./fixtures"`
+	DBSync            bool          `env:"DB_SYNC" envDefault:"false"`
+	DBHost            string        `env:"DB_HOST" envDefault:"localhost"`
+	DBPort            string        `env:"DB_PORT" envDefault:"5432"`
+	DBUser            string        `env:"DB_USER" envDefault:"postgres"`
+	DBPassword        string        `env:"DB_PASSWORD" envDefault:"postgres"`
+	DBName            string        `env:"DB_NAME" envDefault:"postgres"`
+	RedisHost         string        `env:"REDIS_HOST" envDefault:"localhost:6379"`
+	RedisPassword     string        `env:"REDIS_PASSWORD" envDefault:""`
+	S3AccessKeyId     string        `env:"S3_ACCESS_KEY_ID" envDefault:""`
+	S3SecretAccessKey string        `env:"S3_SECRET_ACCESS_KEY" envDefault:""`
+	S3BucketName      string        `env:"S3_BUCKET_NAME" envDefault:""`
+	S3Endpoint        string        `env:"S3_ENDPOINT" envDefault:"https://s3.fr-par.scw.cloud"`
+	ScanCron          string        `env:"SCAN_CRON" envDefault:"*/15 * * * *"`
+	WatchSources      bool          `env:"WATCH_SOURCES" envDefault:"false"`
+	WatchDelay        time.Duration `env:"WATCH_DELAY" envDefault:"1m"`
 }
 
 func LoadEnv() (Env, error) {
@@ -0,0 +1,78 @@
+package initializers
+
+import (
+	"fmt"
+	"github.com/bingemate/media-indexer/pkg"
+	"log"
+	"strings"
+)
+
+// InitMediaClient builds the MediaClient selected by MEDIA_CLIENT_MODE:
+// "tmdb" queries TMDB, "fixture" serves lookups from FIXTURE_FOLDER and "record" queries TMDB while filling FIXTURE_FOLDER.
+// Clients reaching TMDB are rate limited per request and retry the requests rate limited, failing on the server side or not answered,
+// the other failures (e.g. an invalid API key) being reported right away.
+func InitMediaClient(env Env, useRedis bool) (pkg.MediaClient, error) {
+	switch env.MediaClientMode {
+	case "tmdb":
+		tmdbThrottle, err := throttle(env)
+		if err != nil {
+			return nil, err
+		}
+		if useRedis {
+			return pkg.NewRedisMediaClient(env.TMDBApiKey, env.RedisHost, env.RedisPassword, tmdbThrottle), nil
+		}
+		return pkg.NewMediaClient(env.TMDBApiKey, tmdbThrottle), nil
+	case "fixture":
+		log.Println("Serving media lookups from fixtures in", env.FixtureFolder)
+		return pkg.NewFixtureMediaClient(env.FixtureFolder), nil
+	case "record":
+		tmdbThrottle, err := throttle(env)
+		if err != nil {
+			return nil, err
+		}
+		log.Println("Recording media lookups to fixtures in", env.FixtureFolder)
+		return pkg.NewRecordingMediaClient(env.TMDBApiKey, env.FixtureFolder, tmdbThrottle), nil
+	default:
+		return nil, fmt.Errorf("unknown media client mode '%s'", env.MediaClientMode)
+	}
+}
+
+// throttle returns the throttle set by TMDB_RATE_LIMIT, TMDB_RATE_BURST, TMDB_MAX_RETRIES and TMDB_RETRY_BACKOFF
+func throttle(env Env) (*pkg.Throttle, error) {
+	tmdbThrottle, err := pkg.NewThrottle(env.TMDBRateLimit, env.TMDBRateBurst, env.TMDBMaxRetries, env.TMDBRetryBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TMDB throttling: %w", err)
+	}
+	return tmdbThrottle, nil
+}
+
+// InitTreeOptions returns the options telling which files of a source folder are media files.
+// Each scanned source folder needs its own options, as they track the files still being written.
+func InitTreeOptions(env Env) pkg.TreeOptions {
+	return pkg.TreeOptions{
+		Extensions:        normalizeExtensions(env.MediaExtensions),
+		SniffContent:      env.MediaSniffing,
+		IgnorePatterns:    env.IgnorePatterns,
+		SampleMaxSize:     env.SampleMaxSizeMB << 20,
+		SampleMaxDuration: env.SampleMaxDuration,
+		Stability:         pkg.NewStabilityTracker(env.FileSettlePeriod),
+		FollowSymlinks:    env.FollowSymlinks,
+		Workers:           env.WalkWorkers,
+	}
+}
+
+// normalizeExtensions turns the configured media extensions into the form of filepath.Ext, e.g. " MKV" -> ".mkv"
+func normalizeExtensions(extensions []string) []string {
+	var normalized = make([]string, 0, len(extensions))
+	for _, extension := range extensions {
+		extension = strings.ToLower(strings.TrimSpace(extension))
+		if extension == "" {
+			continue
+		}
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		normalized = append(normalized, extension)
+	}
+	return normalized
+}
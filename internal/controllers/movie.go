@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/bingemate/media-indexer/internal/features"
+	"github.com/bingemate/media-indexer/pkg"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+type movieVersionsResponse features.MovieVersionsReport
+
+func InitMovieController(engine *gin.RouterGroup, movieVersionsLister *features.MovieVersionsLister) {
+	engine.GET("/:id/versions", func(c *gin.Context) {
+		getMovieVersions(c, movieVersionsLister)
+	})
+}
+
+// @Summary		Get Movie Versions
+// @Description	List the versions of a movie in the library, each stored in its own folder under "movies/" in the object storage
+// @Tags			Movie
+// @Param			id path int true "TMDB movie ID"
+// @Produce		json
+// @Success		200	{object} movieVersionsResponse
+// @Failure		400	{object} errorResponse
+// @Failure		404	{object} errorResponse
+// @Failure		500	{object} errorResponse
+// @Router			/movie/{id}/versions [get]
+func getMovieVersions(c *gin.Context, movieVersionsLister *features.MovieVersionsLister) {
+	movieID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, errorResponse{Error: "invalid movie id"})
+		return
+	}
+	report, err := movieVersionsLister.ListMovieVersions(movieID)
+	if err != nil {
+		if errors.Is(err, pkg.ErrNotFound) {
+			c.JSON(404, errorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(500, errorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(200, report)
+}
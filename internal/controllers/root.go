@@ -17,18 +17,36 @@ type errorResponse struct {
 func InitRouter(engine *gin.Engine, db *gorm.DB, env initializers.Env) {
 	var mediaIndexerGroup = engine.Group("/media-indexer")
 	engine.MaxMultipartMemory = 32 << 20 // 32 MiB per file upload fragment
-	var mediaClient = pkg.NewRedisMediaClient(env.TMDBApiKey, env.RedisHost, env.RedisPassword)
-	var mediaRepository = repository.NewMediaRepository(db, env.IntroFilePath, env.Intro219FilePath)
+	mediaClient, err := initializers.InitMediaClient(env, true)
+	if err != nil {
+		panic(err)
+	}
+	duplicatePolicy, err := pkg.ParseDuplicatePolicy(env.DuplicatePolicy)
+	if err != nil {
+		panic(err)
+	}
+	var mediaRepository = repository.NewMediaRepository(db, env.IntroFilePath, env.Intro219FilePath, duplicatePolicy)
+	animeMode, err := pkg.ParseAnimeMode(env.TVAnimeMode)
+	if err != nil {
+		panic(err)
+	}
 	objectStorage, err := objectstorage.NewObjectStorage(env.S3AccessKeyId, env.S3SecretAccessKey, env.S3Endpoint, "fr-par", env.S3BucketName)
 	if err != nil {
 		panic(err)
 	}
-	var movieScanner = features.NewMovieScanner(env.MovieSourceFolder, env.MovieTargetFolder, mediaClient, mediaRepository, objectStorage)
-	var tvScanner = features.NewTVScanner(env.TvSourceFolder, env.TvTargetFolder, mediaClient, mediaRepository, objectStorage)
+	var movieScanner = features.NewMovieScanner(env.MovieSourceFolder, env.MovieTargetFolder, mediaClient, mediaRepository, objectStorage, initializers.InitTreeOptions(env), env.NotFoundRetry)
+	var tvScanner = features.NewTVScanner(env.TvSourceFolder, env.TvTargetFolder, mediaClient, mediaRepository, objectStorage, animeMode, initializers.InitTreeOptions(env), env.NotFoundRetry)
 	var mediaUploader = features.NewMediaUploader(env.TvSourceFolder, env.MovieSourceFolder)
+	var missingEpisodesFinder = features.NewMissingEpisodesFinder(mediaClient, mediaRepository)
+	var movieVersionsLister = features.NewMovieVersionsLister(mediaRepository)
 	features.ScheduleScanner(env.ScanCron, movieScanner, tvScanner)
+	if env.WatchSources {
+		features.WatchSources(env.WatchDelay, movieScanner, tvScanner)
+	}
 	InitScanController(mediaIndexerGroup.Group("/scan"), movieScanner, tvScanner)
 	InitUploadController(mediaIndexerGroup.Group("/upload"), mediaUploader)
 	InitJobController(mediaIndexerGroup.Group("/job"))
+	InitTvController(mediaIndexerGroup.Group("/tv"), missingEpisodesFinder)
+	InitMovieController(mediaIndexerGroup.Group("/movie"), movieVersionsLister)
 	InitPingController(mediaIndexerGroup.Group("/ping"))
 }
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/bingemate/media-indexer/internal/features"
+	"github.com/bingemate/media-indexer/pkg"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+type missingEpisodesResponse features.MissingEpisodesReport
+
+func InitTvController(engine *gin.RouterGroup, missingEpisodesFinder *features.MissingEpisodesFinder) {
+	engine.GET("/:id/missing", func(c *gin.Context) {
+		getMissingEpisodes(c, missingEpisodesFinder)
+	})
+}
+
+// @Summary		Get Missing Episodes
+// @Description	List the episodes of a TV show listed by TMDB that are not in the library, aired or not yet aired
+// @Tags			TV
+// @Param			id path int true "TMDB TV show ID"
+// @Produce		json
+// @Success		200	{object} missingEpisodesResponse
+// @Failure		400	{object} errorResponse
+// @Failure		404	{object} errorResponse
+// @Failure		500	{object} errorResponse
+// @Router			/tv/{id}/missing [get]
+func getMissingEpisodes(c *gin.Context, missingEpisodesFinder *features.MissingEpisodesFinder) {
+	tvShowID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, errorResponse{Error: "invalid TV show id"})
+		return
+	}
+	report, err := missingEpisodesFinder.FindMissingEpisodes(tvShowID)
+	if err != nil {
+		if errors.Is(err, pkg.ErrNotFound) {
+			c.JSON(404, errorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(500, errorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(200, report)
+}
@@ -0,0 +1,251 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bingemate/media-indexer/internal/repository"
+	"github.com/bingemate/media-indexer/pkg"
+	"log"
+	"os"
+	"path"
+	"time"
+)
+
+// skipUnchangedMovies returns the movie files which are new or changed since their last scan,
+// or which no match was found for longer than notFoundRetry ago
+func skipUnchangedMovies(mediaRepository *repository.MediaRepository, mediaFiles *[]pkg.MovieFile, notFoundRetry time.Duration) *[]pkg.MovieFile {
+	var changed = make([]pkg.MovieFile, 0, len(*mediaFiles))
+	for _, mediaFile := range *mediaFiles {
+		if !isUnchanged(mediaRepository, path.Join(mediaFile.Path, mediaFile.Filename), mediaFile.Disc, mediaFile.MatchHints(), notFoundRetry) {
+			changed = append(changed, mediaFile)
+		}
+	}
+	return &changed
+}
+
+// skipUnchangedTVShows returns the TV show files which are new or changed since their last scan,
+// or which no match was found for longer than notFoundRetry ago
+func skipUnchangedTVShows(mediaRepository *repository.MediaRepository, mediaFiles *[]pkg.TVShowFile, notFoundRetry time.Duration) *[]pkg.TVShowFile {
+	var changed = make([]pkg.TVShowFile, 0, len(*mediaFiles))
+	for _, mediaFile := range *mediaFiles {
+		if !isUnchanged(mediaRepository, path.Join(mediaFile.Path, mediaFile.Filename), nil, mediaFile.MatchHints(), notFoundRetry) {
+			changed = append(changed, mediaFile)
+		}
+	}
+	return &changed
+}
+
+// skipMovieSamples returns the movie files which are not samples by their duration, recording the samples in the index
+// so that they are not probed again until they change
+func skipMovieSamples(mediaRepository *repository.MediaRepository, treeOptions pkg.TreeOptions, mediaFiles *[]pkg.MovieFile) *[]pkg.MovieFile {
+	var movies = make([]pkg.MovieFile, 0, len(*mediaFiles))
+	for _, mediaFile := range *mediaFiles {
+		if mediaFile.Disc == nil && isShortSample(mediaRepository, treeOptions, path.Join(mediaFile.Path, mediaFile.Filename), mediaFile.MatchHints()) {
+			continue
+		}
+		movies = append(movies, mediaFile)
+	}
+	return &movies
+}
+
+// skipTVShowSamples returns the TV show files which are not samples by their duration, recording the samples in the index
+// so that they are not probed again until they change
+func skipTVShowSamples(mediaRepository *repository.MediaRepository, treeOptions pkg.TreeOptions, mediaFiles *[]pkg.TVShowFile) *[]pkg.TVShowFile {
+	var episodes = make([]pkg.TVShowFile, 0, len(*mediaFiles))
+	for _, mediaFile := range *mediaFiles {
+		if !isShortSample(mediaRepository, treeOptions, path.Join(mediaFile.Path, mediaFile.Filename), mediaFile.MatchHints()) {
+			episodes = append(episodes, mediaFile)
+		}
+	}
+	return &episodes
+}
+
+// skipDuplicateMovies hashes the content of the movie files and returns the ones which are not identical to the source
+// of a media file already in the library, the duplicates being removed like indexed files without looking them up
+func skipDuplicateMovies(mediaRepository *repository.MediaRepository, mediaFiles *[]pkg.MovieFile) *[]pkg.MovieFile {
+	var movies = make([]pkg.MovieFile, 0, len(*mediaFiles))
+	for _, mediaFile := range *mediaFiles {
+		mediaFile.HashContent()
+		var source = path.Join(mediaFile.Path, mediaFile.Filename)
+		if isDuplicateSource(mediaRepository, source, mediaFile.ContentHash) {
+			recordOutcome(mediaRepository, source, mediaFile.Disc, mediaFile.MatchHints(), repository.SourceFileIndexed, nil)
+			removeSource(source, mediaFile.Disc != nil, mediaFile.Subtitles)
+			continue
+		}
+		movies = append(movies, mediaFile)
+	}
+	return &movies
+}
+
+// skipDuplicateTVShows hashes the content of the TV show files and returns the ones which are not identical to the source
+// of a media file already in the library, the duplicates being removed like indexed files without looking them up
+func skipDuplicateTVShows(mediaRepository *repository.MediaRepository, mediaFiles *[]pkg.TVShowFile) *[]pkg.TVShowFile {
+	var episodes = make([]pkg.TVShowFile, 0, len(*mediaFiles))
+	for _, mediaFile := range *mediaFiles {
+		mediaFile.HashContent()
+		var source = path.Join(mediaFile.Path, mediaFile.Filename)
+		if isDuplicateSource(mediaRepository, source, mediaFile.ContentHash) {
+			recordOutcome(mediaRepository, source, nil, mediaFile.MatchHints(), repository.SourceFileIndexed, nil)
+			removeSource(source, false, mediaFile.Subtitles)
+			continue
+		}
+		episodes = append(episodes, mediaFile)
+	}
+	return &episodes
+}
+
+// isShortSample tells whether a source file is a sample by its duration, logging it and recording it in the index if so
+func isShortSample(mediaRepository *repository.MediaRepository, treeOptions pkg.TreeOptions, filePath, hints string) bool {
+	reason, ok := treeOptions.IsShortSample(filePath)
+	if !ok {
+		return false
+	}
+	log.Printf("Ignoring sample %s (%s)", filePath, reason)
+	pkg.AppendJobLog(fmt.Sprintf("Ignoring sample %s (%s)", filePath, reason))
+	recordOutcome(mediaRepository, filePath, nil, hints, repository.SourceFileSample, nil)
+	return true
+}
+
+// isUnchanged tells whether a source file was indexed, found unmatchable, found to be a sample or kept in place by a previous scan,
+// with the same size, modification time and match hints as now, the ones of a disc being taken from its stream files.
+// A file no match was found for is looked up again once notFoundRetry is over, 0 never looking it up again.
+func isUnchanged(mediaRepository *repository.MediaRepository, filePath string, disc *pkg.Disc, hints string, notFoundRetry time.Duration) bool {
+	size, modTime, err := statSource(filePath, disc)
+	if err != nil {
+		return false
+	}
+	sourceFile, err := mediaRepository.FindSourceFile(filePath)
+	if err != nil {
+		log.Printf("Failed to read the index entry of %s: %v", filePath, err)
+		return false
+	}
+	if sourceFile == nil || sourceFile.Size != size || sourceFile.ModTime != modTime.UnixNano() || sourceFile.Hints != hints {
+		return false
+	}
+	switch sourceFile.Outcome {
+	case repository.SourceFileNotFound:
+		if notFoundRetry > 0 && time.Since(sourceFile.UpdatedAt) >= notFoundRetry {
+			log.Printf("Retrying %s, no match was found on %s", filePath, sourceFile.UpdatedAt.Format(time.DateTime))
+			pkg.AppendJobLog(fmt.Sprintf("Retrying %s, no match was found on %s", filePath, sourceFile.UpdatedAt.Format(time.DateTime)))
+			return false
+		}
+		log.Printf("Skipping %s, no match was found on last scan and neither the file nor its match hints changed", filePath)
+		pkg.AppendJobLog(fmt.Sprintf("Skipping %s, no match was found on last scan and neither the file nor its match hints changed", filePath))
+		return true
+	case repository.SourceFileDuplicate:
+		log.Printf("Skipping %s, kept in place by the duplicate policy on last scan and unchanged", filePath)
+		pkg.AppendJobLog(fmt.Sprintf("Skipping %s, kept in place by the duplicate policy on last scan and unchanged", filePath))
+		return true
+	case repository.SourceFileSample:
+		log.Printf("Skipping %s, found to be a sample on last scan and unchanged", filePath)
+		pkg.AppendJobLog(fmt.Sprintf("Skipping %s, found to be a sample on last scan and unchanged", filePath))
+		return true
+	case repository.SourceFileIndexed:
+		log.Printf("Skipping %s, already indexed and unchanged", filePath)
+		pkg.AppendJobLog(fmt.Sprintf("Skipping %s, already indexed and unchanged", filePath))
+		return true
+	}
+	return false
+}
+
+// pruneSourceFiles removes from the index the source files of a folder which no longer exist,
+// e.g. moved to the library or deleted by hand
+func pruneSourceFiles(mediaRepository *repository.MediaRepository, folder string) {
+	paths, err := mediaRepository.FindSourceFilePaths(folder)
+	if err != nil {
+		log.Printf("Failed to list the index entries of %s: %v", folder, err)
+		return
+	}
+	var removed = make([]string, 0)
+	for _, filePath := range paths {
+		if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+			removed = append(removed, filePath)
+		}
+	}
+	if err = mediaRepository.DeleteSourceFiles(removed); err != nil {
+		log.Printf("Failed to prune the index entries of %s: %v", folder, err)
+		return
+	}
+	if len(removed) > 0 {
+		log.Printf("Pruned %d index entries of files no longer in %s", len(removed), folder)
+		pkg.AppendJobLog(fmt.Sprintf("Pruned %d index entries of files no longer in %s", len(removed), folder))
+	}
+}
+
+// recordLookupFailure stores in the index that no match could be found for a source file
+func recordLookupFailure(mediaRepository *repository.MediaRepository, filePath string, disc *pkg.Disc, hints string, err error) {
+	var outcome = repository.SourceFileProviderError
+	if errors.Is(err, pkg.ErrNotFound) {
+		outcome = repository.SourceFileNotFound
+	}
+	recordOutcome(mediaRepository, filePath, disc, hints, outcome, err)
+}
+
+// recordOutcome stores the outcome of the scan of a source file in the index, logging instead of failing
+// since the file is then only scanned again on the next run
+func recordOutcome(mediaRepository *repository.MediaRepository, filePath string, disc *pkg.Disc, hints, outcome string, err error) {
+	size, modTime, statErr := statSource(filePath, disc)
+	if statErr != nil {
+		log.Printf("Failed to index the outcome of %s: %v", filePath, statErr)
+		return
+	}
+	sourceFile := repository.SourceFile{
+		Path:    filePath,
+		Size:    size,
+		ModTime: modTime.UnixNano(),
+		Hints:   hints,
+		Outcome: outcome,
+	}
+	if err != nil {
+		sourceFile.Error = err.Error()
+	}
+	if err = mediaRepository.SaveSourceFile(&sourceFile); err != nil {
+		log.Printf("Failed to index the outcome of %s: %v", filePath, err)
+	}
+}
+
+// statSource returns the size and the modification time of a source file, or the ones of the stream files of a disc
+// since the disc folder is left untouched when they change
+func statSource(filePath string, disc *pkg.Disc) (int64, time.Time, error) {
+	if disc != nil {
+		return disc.Stat()
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return info.Size(), info.ModTime(), nil
+}
+
+// isDuplicateSource tells whether a source file has the same content as the source of a media file already in the library
+func isDuplicateSource(mediaRepository *repository.MediaRepository, filePath, contentHash string) bool {
+	if contentHash == "" {
+		return false
+	}
+	mediaFileInfo, err := mediaRepository.FindMediaFileInfoByContentHash(contentHash)
+	if err != nil {
+		log.Printf("Failed to look for duplicates of %s: %v", filePath, err)
+		return false
+	}
+	if mediaFileInfo == nil {
+		return false
+	}
+	log.Printf("Skipping %s, identical to %s already indexed as media file %s", filePath, mediaFileInfo.SourceFilename, mediaFileInfo.MediaFileID)
+	pkg.AppendJobLog(fmt.Sprintf("Skipping %s, identical to %s already indexed as media file %s", filePath, mediaFileInfo.SourceFilename, mediaFileInfo.MediaFileID))
+	return true
+}
+
+// isDuplicateKept tells whether a source file was not indexed because the duplicate policy keeps the file already in the library.
+// A file losing to the indexed one is removed like an indexed file, while a file to keep alongside it is left in place until it changes.
+func isDuplicateKept(mediaRepository *repository.MediaRepository, filePath string, disc *pkg.Disc, hints string, subtitles []pkg.SubtitleFile, err error) bool {
+	switch {
+	case errors.Is(err, repository.ErrDuplicateKept):
+		recordOutcome(mediaRepository, filePath, disc, hints, repository.SourceFileIndexed, nil)
+		removeSource(filePath, disc != nil, subtitles)
+		return true
+	case errors.Is(err, repository.ErrDuplicateVersion):
+		recordOutcome(mediaRepository, filePath, disc, hints, repository.SourceFileDuplicate, err)
+		return true
+	}
+	return false
+}
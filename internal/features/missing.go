@@ -0,0 +1,86 @@
+package features
+
+import (
+	"github.com/bingemate/media-indexer/internal/repository"
+	"github.com/bingemate/media-indexer/pkg"
+	"time"
+)
+
+// MissingEpisodesFinder compares the episodes of a TV show in the library with the episodes listed by TMDB.
+type MissingEpisodesFinder struct {
+	mediaClient     pkg.MediaClient             // Media client object to list the seasons and episodes of a TV show on TMDB.
+	mediaRepository *repository.MediaRepository // Media repository object to retrieve the indexed episodes.
+}
+
+// MissingEpisode represents an episode listed by TMDB that is not in the library.
+type MissingEpisode struct {
+	Season  int    `json:"season" example:"1"`
+	Episode int    `json:"episode" example:"3"`
+	Name    string `json:"name" example:"Pilot"`
+	AirDate string `json:"airDate" example:"2008-01-20"`
+}
+
+// MissingEpisodesReport holds the gaps of a TV show in the library.
+type MissingEpisodesReport struct {
+	TvShowID    int              `json:"tvShowId" example:"1396"`
+	TvShowName  string           `json:"tvShowName" example:"Breaking Bad"`
+	Aired       []MissingEpisode `json:"aired"`       // Episodes already aired but not in the library
+	NotYetAired []MissingEpisode `json:"notYetAired"` // Episodes announced by TMDB but not aired yet
+}
+
+// NewMissingEpisodesFinder returns a new instance of MissingEpisodesFinder.
+func NewMissingEpisodesFinder(mediaClient pkg.MediaClient, mediaRepository *repository.MediaRepository) *MissingEpisodesFinder {
+	return &MissingEpisodesFinder{
+		mediaClient:     mediaClient,
+		mediaRepository: mediaRepository,
+	}
+}
+
+// FindMissingEpisodes lists the episodes of the regular seasons of a TV show that are not in the library,
+// split between the ones already aired and the ones not aired yet.
+func (f *MissingEpisodesFinder) FindMissingEpisodes(tvShowID int) (*MissingEpisodesReport, error) {
+	tvShow, err := f.mediaClient.GetTVShow(tvShowID)
+	if err != nil {
+		return nil, err
+	}
+	indexed, err := f.mediaRepository.FindIndexedEpisodes(tvShowID)
+	if err != nil {
+		return nil, err
+	}
+
+	var report = &MissingEpisodesReport{
+		TvShowID:    tvShow.ID,
+		TvShowName:  tvShow.Name,
+		Aired:       make([]MissingEpisode, 0),
+		NotYetAired: make([]MissingEpisode, 0),
+	}
+	var today = time.Now().Format("2006-01-02")
+	for _, season := range tvShow.Seasons {
+		// Specials are not part of the regular seasons
+		if season.Number == 0 {
+			continue
+		}
+		episodes, err := f.mediaClient.GetTVSeasonEpisodes(tvShowID, season.Number)
+		if err != nil {
+			return nil, err
+		}
+		for _, episode := range episodes {
+			if indexed[episode.Season][episode.Episode] {
+				continue
+			}
+			missing := MissingEpisode{
+				Season:  episode.Season,
+				Episode: episode.Episode,
+				Name:    episode.EpisodeName,
+				AirDate: episode.ReleaseDate,
+			}
+			// TMDB dates are ISO formatted, so they can be compared as strings
+			if episode.ReleaseDate == "" || episode.ReleaseDate > today {
+				report.NotYetAired = append(report.NotYetAired, missing)
+			} else {
+				report.Aired = append(report.Aired, missing)
+			}
+		}
+	}
+	return report, nil
+}
@@ -21,6 +21,8 @@ type MovieScanner struct {
 	mediaClient     pkg.MediaClient             // Media client object to search for movies on TMDB.
 	mediaRepository *repository.MediaRepository // Media repository object to save the media files and their details.
 	objectStorage   objectStorage.ObjectStorage // Object storage object to upload the media files.
+	treeOptions     pkg.TreeOptions             // Tells which files of the source directory are media files.
+	notFoundRetry   time.Duration               // Delay before looking up again the files no match was found for, 0 to never retry.
 }
 
 // MovieScannerResult represents a struct that holds the results of scanning and moving movie files.
@@ -36,39 +38,53 @@ type TVScanner struct {
 	mediaClient     pkg.MediaClient             // Media client object to search for TV shows on TMDB.
 	mediaRepository *repository.MediaRepository // Media repository object to save the media files and their details.
 	objectStorage   objectStorage.ObjectStorage // Object storage object to upload the media files.
+	animeMode       pkg.AnimeMode               // Tells which TV show files are numbered with absolute episode numbers.
+	treeOptions     pkg.TreeOptions             // Tells which files of the source directory are media files.
+	notFoundRetry   time.Duration               // Delay before looking up again the files no match was found for, 0 to never retry.
 }
 
 // TVScannerResult represents a struct that holds the results of scanning and moving TV show files.
 type TVScannerResult struct {
-	Source    string        // Source filename.
-	TVEpisode pkg.TVEpisode // TV episode details returned by TMDB.
+	Source     string          // Source filename.
+	TVEpisodes []pkg.TVEpisode // TV episodes details returned by TMDB, several for multi-episode files.
 }
 
 // NewMovieScanner returns a new instance of MovieScanner with given source directory, target directory, and TMDB API key.
-func NewMovieScanner(source, destination string, mediaClient pkg.MediaClient, mediaRepository *repository.MediaRepository, objectStorage objectStorage.ObjectStorage) *MovieScanner {
+func NewMovieScanner(source, destination string, mediaClient pkg.MediaClient, mediaRepository *repository.MediaRepository, objectStorage objectStorage.ObjectStorage, treeOptions pkg.TreeOptions, notFoundRetry time.Duration) *MovieScanner {
 	return &MovieScanner{
 		source:          source,
 		destination:     destination,
 		mediaClient:     mediaClient,
 		mediaRepository: mediaRepository,
 		objectStorage:   objectStorage,
+		treeOptions:     treeOptions,
+		notFoundRetry:   notFoundRetry,
 	}
 }
 
 // NewTVScanner returns a new instance of TVScanner with given source directory, target directory, and TMDB API key.
-func NewTVScanner(source, destination string, mediaClient pkg.MediaClient, mediaRepository *repository.MediaRepository, objectStorage objectStorage.ObjectStorage) *TVScanner {
+func NewTVScanner(source, destination string, mediaClient pkg.MediaClient, mediaRepository *repository.MediaRepository, objectStorage objectStorage.ObjectStorage, animeMode pkg.AnimeMode, treeOptions pkg.TreeOptions, notFoundRetry time.Duration) *TVScanner {
 	return &TVScanner{
 		source:          source,
 		destination:     destination,
 		mediaClient:     mediaClient,
 		mediaRepository: mediaRepository,
 		objectStorage:   objectStorage,
+		animeMode:       animeMode,
+		treeOptions:     treeOptions,
+		notFoundRetry:   notFoundRetry,
 	}
 }
 
 // ScanMovies scans the source directory for movies and moves them to the destination directory.
 // It returns a slice of MovieScannerResult and an error if there is any.
 func (s *MovieScanner) ScanMovies() error {
+	return s.ScanMovieSubtree(s.source)
+}
+
+// ScanMovieSubtree scans a subtree of the source directory for movies and moves them to the destination directory.
+// It returns an error if a job is already running.
+func (s *MovieScanner) ScanMovieSubtree(subtree string) error {
 	// Locks the scanner to prevent concurrent scanning
 	locked := jobLock.TryLock()
 	if !locked {
@@ -80,12 +96,16 @@ func (s *MovieScanner) ScanMovies() error {
 		defer jobLock.Unlock()
 		pkg.ClearJobLogs("scan movies")
 
-		mediaFiles, err := s.scanMovieFolder()
+		mediaFiles, err := s.scanMovieFolder(subtree)
 		if err != nil {
 			log.Printf("Failed to scan movie folder: %v", err)
 			pkg.AppendJobLog(fmt.Sprintf("Failed to scan movie folder: %v", err))
 			return
 		}
+		pruneSourceFiles(s.mediaRepository, subtree)
+		mediaFiles = skipUnchangedMovies(s.mediaRepository, mediaFiles, s.notFoundRetry)
+		mediaFiles = skipMovieSamples(s.mediaRepository, s.treeOptions, mediaFiles)
+		mediaFiles = skipDuplicateMovies(s.mediaRepository, mediaFiles)
 		atomicMovieList := s.retrieveMovieList(mediaFiles)
 
 		result := s.buildMovieScannerResult(atomicMovieList)
@@ -124,21 +144,22 @@ func (s *MovieScanner) buildMovieScannerResult(atomicMediaList *pkg.AtomicMovieL
 	return &result
 }
 
-func (s *MovieScanner) scanMovieFolder() (*[]pkg.MovieFile, error) {
+func (s *MovieScanner) scanMovieFolder(subtree string) (*[]pkg.MovieFile, error) {
 	// Logs that the function is scanning the source directory for movies
-	log.Printf("Scanning %s for movies...", s.source)
-	pkg.AppendJobLog(fmt.Sprintf("Scanning %s for movies...", s.source))
+	log.Printf("Scanning %s for movies...", subtree)
+	pkg.AppendJobLog(fmt.Sprintf("Scanning %s for movies...", subtree))
 
 	// Builds the directory tree from the source directory and returns an error if it fails
-	mediaFiles, err := pkg.BuildMovieTree(s.source)
+	mediaFiles, walkReport, err := pkg.BuildMovieSubtree(s.source, subtree, s.treeOptions)
 	if err != nil {
 		log.Printf("Failed to scan source tree: %v", err)
 		pkg.AppendJobLog(fmt.Sprintf("Failed to scan source tree: %v", err))
 		return nil, err
 	}
 
-	log.Printf("Scanning %d files in %s...", len(mediaFiles), s.source)
-	pkg.AppendJobLog(fmt.Sprintf("Scanning %d files in %s...", len(mediaFiles), s.source))
+	logWalkReport(subtree, walkReport)
+	log.Printf("Scanning %d files in %s...", len(mediaFiles), subtree)
+	pkg.AppendJobLog(fmt.Sprintf("Scanning %d files in %s...", len(mediaFiles), subtree))
 	return &mediaFiles, nil
 }
 
@@ -146,28 +167,35 @@ func (s *MovieScanner) retrieveMovieList(mediaFiles *[]pkg.MovieFile) *pkg.Atomi
 	// Initialize a WaitGroup and an AtomicMovieList
 	var wg sync.WaitGroup
 	var atomicMovieList = pkg.NewAtomicMovieList()
+	var report = &lookupReport{}
 
 	// Create a semaphore channel to limit the number of goroutines
 	sem := make(chan bool, 4)
 
-	for _, mediaFile := range *mediaFiles {
+	for i := range *mediaFiles {
 		sem <- true
 		wg.Add(1)
-		go func(mediaFile pkg.MovieFile) {
+		go func(mediaFile *pkg.MovieFile) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
 			log.Printf("Searching for movie information for file %s...", mediaFile.Filename)
 			pkg.AppendJobLog(fmt.Sprintf("Searching for movie information for file %s...", mediaFile.Filename))
+			if mediaFile.NFO != "" {
+				log.Printf("Using NFO file %s as match hints for file %s", mediaFile.NFO, mediaFile.Filename)
+				pkg.AppendJobLog(fmt.Sprintf("Using NFO file %s as match hints for file %s", mediaFile.NFO, mediaFile.Filename))
+			}
 
-			media, ok := searchMovie(&mediaFile, s.mediaClient)
-			if !ok {
-				log.Printf("Failed to find movie information for file %s.", mediaFile.Filename)
-				pkg.AppendJobLog(fmt.Sprintf("Failed to find movie information for file %s.", mediaFile.Filename))
+			media, err := searchMovie(mediaFile, s.mediaClient)
+			if err != nil {
+				report.add(err)
+				logLookupFailure("movie", mediaFile.Filename, err)
+				recordLookupFailure(s.mediaRepository, path.Join(mediaFile.Path, mediaFile.Filename), mediaFile.Disc, mediaFile.MatchHints(), err)
 				return
 			}
+			report.add(nil)
 			atomicMovieList.LinkMediaFile(mediaFile, media)
-		}(mediaFile)
+		}(&(*mediaFiles)[i])
 	}
 
 	for i := 0; i < cap(sem); i++ {
@@ -176,14 +204,20 @@ func (s *MovieScanner) retrieveMovieList(mediaFiles *[]pkg.MovieFile) *pkg.Atomi
 
 	wg.Wait()
 
-	log.Println("Movie scan complete.")
-	pkg.AppendJobLog("Movie scan complete.")
+	log.Printf("Movie scan complete. %s", report)
+	pkg.AppendJobLog(fmt.Sprintf("Movie scan complete. %s", report))
 	return atomicMovieList
 }
 
 // ScanTV scans the source directory for TV shows and moves them to the destination directory.
 // It returns a slice of TVScannerResult and an error if there is any.
 func (s *TVScanner) ScanTV() error {
+	return s.ScanTVSubtree(s.source)
+}
+
+// ScanTVSubtree scans a subtree of the source directory for TV shows and moves them to the destination directory.
+// It returns an error if a job is already running.
+func (s *TVScanner) ScanTVSubtree(subtree string) error {
 	// Locks the scanner to prevent concurrent scanning
 	locked := jobLock.TryLock()
 	if !locked {
@@ -196,17 +230,23 @@ func (s *TVScanner) ScanTV() error {
 
 		defer jobLock.Unlock()
 
-		mediaFiles, err := s.scanTVFolder()
+		mediaFiles, err := s.scanTVFolder(subtree)
 		if err != nil {
 			log.Printf("Failed to scan TV folder: %v", err)
 			pkg.AppendJobLog(fmt.Sprintf("Failed to scan TV folder: %v", err))
 			return
 		}
 
+		pruneSourceFiles(s.mediaRepository, subtree)
+		mediaFiles = skipUnchangedTVShows(s.mediaRepository, mediaFiles, s.notFoundRetry)
+		mediaFiles = skipTVShowSamples(s.mediaRepository, s.treeOptions, mediaFiles)
+		mediaFiles = skipDuplicateTVShows(s.mediaRepository, mediaFiles)
 		atomicMediaList := s.retrieveTvList(mediaFiles)
 
 		result := s.buildTVScannerResult(atomicMediaList)
 
+		tvShowIDs := s.refreshTvShows(atomicMediaList)
+
 		// Moves the TV shows to the destination directory and returns an error if it fails
 		err = s.processTVEpisodes(atomicMediaList, s.destination)
 		if err != nil {
@@ -214,6 +254,8 @@ func (s *TVScanner) ScanTV() error {
 			pkg.AppendJobLog(fmt.Sprintf("Failed to process TV shows to %s: %v", s.destination, err))
 		}
 
+		s.reportMissingEpisodes(tvShowIDs)
+
 		log.Printf("Processed %d TV shows to %s.", len(*result), s.destination)
 		pkg.AppendJobLog(fmt.Sprintf("Processed %d TV shows to %s.", len(*result), s.destination))
 
@@ -234,8 +276,8 @@ func (s *TVScanner) buildTVScannerResult(atomicMediaList *pkg.AtomicTVEpisodeLis
 	// Iterates through each media file and its corresponding TV show information in the AtomicMovieList and adds it to the result slice
 	for mediaFile, media := range atomicMediaList.GetAll() {
 		result = append(result, TVScannerResult{
-			Source:    mediaFile.Filename,
-			TVEpisode: media,
+			Source:     mediaFile.Filename,
+			TVEpisodes: media,
 		})
 	}
 	return &result
@@ -244,32 +286,51 @@ func (s *TVScanner) buildTVScannerResult(atomicMediaList *pkg.AtomicTVEpisodeLis
 func (s *TVScanner) retrieveTvList(mediaFiles *[]pkg.TVShowFile) *pkg.AtomicTVEpisodeList {
 	var wg sync.WaitGroup
 	var atomicMediaList = pkg.NewAtomicTVEpisodeList()
+	var report = &lookupReport{}
 
 	// Create a semaphore channel to limit the number of goroutines
 	sem := make(chan bool, 4)
 
-	for _, mediaFile := range *mediaFiles {
+	for i := range *mediaFiles {
 		sem <- true
 		wg.Add(1)
-		go func(mediaFile pkg.TVShowFile) {
+		go func(mediaFile *pkg.TVShowFile) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
 			log.Printf("Searching for TV show information for file %s...", mediaFile.Filename)
 			pkg.AppendJobLog(fmt.Sprintf("Searching for TV show information for file %s...", mediaFile.Filename))
+			if mediaFile.NFO != "" {
+				log.Printf("Using NFO file %s as match hints for file %s", mediaFile.NFO, mediaFile.Filename)
+				pkg.AppendJobLog(fmt.Sprintf("Using NFO file %s as match hints for file %s", mediaFile.NFO, mediaFile.Filename))
+			}
 
-			media, ok := searchTVEpisode(&mediaFile, s.mediaClient)
-			if !ok {
-				log.Printf("Failed to find TV show information for file %s.", mediaFile.Filename)
-				pkg.AppendJobLog(fmt.Sprintf("Failed to find TV show information for file %s.", mediaFile.Filename))
+			// A multi-episode file is indexed with the episodes found, as long as there is at least one
+			var media = make([]pkg.TVEpisode, 0)
+			var lookupErr error
+			for _, episode := range mediaFile.Episodes() {
+				tvEpisode, err := searchTVEpisode(mediaFile, episode, s.mediaClient)
+				if err != nil {
+					lookupErr = err
+					logLookupFailure("TV show", fmt.Sprintf("%s (episode %d)", mediaFile.Filename, episode), err)
+					continue
+				}
+				media = append(media, tvEpisode)
+			}
+			if len(media) == 0 {
+				report.add(lookupErr)
+				recordLookupFailure(s.mediaRepository, path.Join(mediaFile.Path, mediaFile.Filename), nil, mediaFile.MatchHints(), lookupErr)
 				return
 			}
+			report.add(nil)
 			log.Printf("Found TV show information for file %s:", mediaFile.Filename)
 			pkg.AppendJobLog(fmt.Sprintf("Found TV show information for file %s:", mediaFile.Filename))
-			log.Println(media)
-			pkg.AppendJobLog(fmt.Sprintf("%v", media))
+			for _, tvEpisode := range media {
+				log.Println(tvEpisode)
+				pkg.AppendJobLog(fmt.Sprintf("%v", tvEpisode))
+			}
 			atomicMediaList.LinkMediaFile(mediaFile, media)
-		}(mediaFile)
+		}(&(*mediaFiles)[i])
 	}
 
 	for i := 0; i < cap(sem); i++ {
@@ -278,49 +339,163 @@ func (s *TVScanner) retrieveTvList(mediaFiles *[]pkg.TVShowFile) *pkg.AtomicTVEp
 
 	wg.Wait()
 
-	log.Println("TV show scan complete.")
-	pkg.AppendJobLog("TV show scan complete.")
+	log.Printf("TV show scan complete. %s", report)
+	pkg.AppendJobLog(fmt.Sprintf("TV show scan complete. %s", report))
 	return atomicMediaList
 }
 
-func (s *TVScanner) scanTVFolder() (*[]pkg.TVShowFile, error) {
+// refreshTvShows retrieves the show-level details and seasons of every TV show found during the scan and saves them.
+// It returns the IDs of the TV shows found.
+func (s *TVScanner) refreshTvShows(atomicMediaList *pkg.AtomicTVEpisodeList) []int {
+	var tvShowIDs = make([]int, 0)
+	var seen = make(map[int]bool)
+	for _, episodes := range atomicMediaList.GetAll() {
+		media := episodes[0]
+		if seen[media.TvShowID] {
+			continue
+		}
+		seen[media.TvShowID] = true
+		tvShowIDs = append(tvShowIDs, media.TvShowID)
+
+		tvShow, err := s.mediaClient.GetTVShow(media.TvShowID)
+		if err != nil {
+			log.Printf("Failed to retrieve TV show %s details: %v", media.TvShowName, err)
+			pkg.AppendJobLog(fmt.Sprintf("Failed to retrieve TV show %s details: %v", media.TvShowName, err))
+			continue
+		}
+		err = s.mediaRepository.SaveTvShow(tvShow)
+		if err != nil {
+			log.Printf("Failed to save TV show %s details: %v", media.TvShowName, err)
+			pkg.AppendJobLog(fmt.Sprintf("Failed to save TV show %s details: %v", media.TvShowName, err))
+		}
+	}
+	return tvShowIDs
+}
+
+// reportMissingEpisodes logs, for each given TV show, the aired episodes of its regular seasons that are not in the library
+func (s *TVScanner) reportMissingEpisodes(tvShowIDs []int) {
+	var missingEpisodesFinder = NewMissingEpisodesFinder(s.mediaClient, s.mediaRepository)
+	for _, tvShowID := range tvShowIDs {
+		report, err := missingEpisodesFinder.FindMissingEpisodes(tvShowID)
+		if err != nil {
+			log.Printf("Failed to find missing episodes of TV show %d: %v", tvShowID, err)
+			pkg.AppendJobLog(fmt.Sprintf("Failed to find missing episodes of TV show %d: %v", tvShowID, err))
+			continue
+		}
+		var missing = make(map[int][]int)
+		var seasons = make([]int, 0)
+		for _, episode := range report.Aired {
+			if missing[episode.Season] == nil {
+				seasons = append(seasons, episode.Season)
+			}
+			missing[episode.Season] = append(missing[episode.Season], episode.Episode)
+		}
+		for _, season := range seasons {
+			log.Printf("%s (%d) season %d is missing episodes %v", report.TvShowName, tvShowID, season, missing[season])
+			pkg.AppendJobLog(fmt.Sprintf("%s (%d) season %d is missing episodes %v", report.TvShowName, tvShowID, season, missing[season]))
+		}
+	}
+}
+
+func (s *TVScanner) scanTVFolder(subtree string) (*[]pkg.TVShowFile, error) {
 	// Logs that the function is scanning the source directory for TV shows
-	log.Printf("Scanning %s for TV shows...", s.source)
-	pkg.AppendJobLog(fmt.Sprintf("Scanning %s for TV shows...", s.source))
+	log.Printf("Scanning %s for TV shows...", subtree)
+	pkg.AppendJobLog(fmt.Sprintf("Scanning %s for TV shows...", subtree))
 
 	// Builds the directory tree from the source directory and returns an error if it fails
-	mediaFiles, err := pkg.BuildTVShowTree(s.source)
+	mediaFiles, walkReport, err := pkg.BuildTVShowSubtree(s.source, subtree, s.animeMode, s.treeOptions)
 	if err != nil {
 		log.Printf("Failed to scan source tree: %v", err)
 		pkg.AppendJobLog(fmt.Sprintf("Failed to scan source tree: %v", err))
 		return nil, err
 	}
 
-	log.Printf("Scanning %d files in %s...", len(mediaFiles), s.source)
-	pkg.AppendJobLog(fmt.Sprintf("Scanning %d files in %s...", len(mediaFiles), s.source))
+	logWalkReport(subtree, walkReport)
+	log.Printf("Scanning %d files in %s...", len(mediaFiles), subtree)
+	pkg.AppendJobLog(fmt.Sprintf("Scanning %d files in %s...", len(mediaFiles), subtree))
 	return &mediaFiles, nil
 }
 
-// searchMovie searches for a movie on TMDB using the media file name and year, returning the movie details and a boolean indicating whether it was found.
-func searchMovie(mediaFile *pkg.MovieFile, client pkg.MediaClient) (pkg.Movie, bool) {
-	result, err := client.SearchMovie(mediaFile.SanitizedName, mediaFile.Year)
+// logWalkReport adds the unreadable paths and the throughput of the walk of a source folder to the job report
+func logWalkReport(subtree string, report pkg.WalkReport) {
+	for _, warning := range report.Warnings {
+		pkg.AppendJobLog(fmt.Sprintf("Warning: skipped unreadable path %s", warning))
+	}
+	log.Printf("Walked %s: %s", subtree, report)
+	pkg.AppendJobLog(fmt.Sprintf("Walked %s: %s", subtree, report))
+}
+
+// lookupReport counts the outcome of the metadata lookups of a scan.
+type lookupReport struct {
+	lock           sync.Mutex
+	found          int // Files matched with TMDB
+	notFound       int // Files without any match on TMDB
+	providerErrors int // Files whose lookup failed because of TMDB, retried on the next scan
+}
+
+func (r *lookupReport) add(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	switch {
+	case err == nil:
+		r.found++
+	case errors.Is(err, pkg.ErrNotFound):
+		r.notFound++
+	default:
+		r.providerErrors++
+	}
+}
+
+func (r *lookupReport) String() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return fmt.Sprintf("%d found, %d not found, %d provider errors.", r.found, r.notFound, r.providerErrors)
+}
+
+// logLookupFailure reports a failed lookup, telling apart unmatched files from provider errors
+func logLookupFailure(mediaType, filename string, err error) {
+	if errors.Is(err, pkg.ErrNotFound) {
+		log.Printf("Failed to find %s information for file %s: %s", mediaType, filename, err.Error())
+		pkg.AppendJobLog(fmt.Sprintf("Failed to find %s information for file %s: %s", mediaType, filename, err.Error()))
+		return
+	}
+	log.Printf("Provider error while searching %s information for file %s, it will be retried on next scan: %s", mediaType, filename, err.Error())
+	pkg.AppendJobLog(fmt.Sprintf("Provider error while searching %s information for file %s, it will be retried on next scan: %s", mediaType, filename, err.Error()))
+}
+
+// searchMovie searches for a movie on TMDB using the media file name and year, or its ID tags if any, returning the movie details.
+// The returned error wraps pkg.ErrNotFound if the movie is unknown and pkg.ErrProvider if TMDB failed to answer.
+func searchMovie(mediaFile *pkg.MovieFile, client pkg.MediaClient) (pkg.Movie, error) {
+	result, err := client.SearchMovie(mediaFile.SanitizedName, mediaFile.Year, mediaFile.IDs)
 	if err != nil {
 		log.Printf("Error while media search on %s : %s. Sanitized name was : %s", mediaFile.Filename, err.Error(), mediaFile.SanitizedName)
 		pkg.AppendJobLog(fmt.Sprintf("Error while media search on %s : %s. Sanitized name was : %s", mediaFile.Filename, err.Error(), mediaFile.SanitizedName))
-		return pkg.Movie{}, false
+		return pkg.Movie{}, err
 	}
-	return result, true
+	return result, nil
 }
 
-// searchTVEpisode searches for a TV show on TMDB using the media file name and year, returning the TV show details and a boolean indicating whether it was found.
-func searchTVEpisode(mediaFile *pkg.TVShowFile, client pkg.MediaClient) (pkg.TVEpisode, bool) {
-	result, err := client.SearchTVShow(mediaFile.SanitizedName, mediaFile.Season, mediaFile.Episode)
+// searchTVEpisode searches for an episode of a TV show on TMDB using the media file name or its ID tags and the season, returning the episode details.
+// The returned error wraps pkg.ErrNotFound if the episode is unknown and pkg.ErrProvider if TMDB failed to answer.
+// Absolute episode numbers (anime), air dates (daily shows) and special names are mapped to their season and episode on TMDB.
+func searchTVEpisode(mediaFile *pkg.TVShowFile, episode int, client pkg.MediaClient) (pkg.TVEpisode, error) {
+	var result pkg.TVEpisode
+	var err error
+	if mediaFile.AirDate != "" {
+		result, err = client.SearchTVShowByDate(mediaFile.SanitizedName, mediaFile.IDs, mediaFile.AirDate)
+	} else if mediaFile.SpecialName != "" {
+		result, err = client.SearchTVShowSpecial(mediaFile.SanitizedName, mediaFile.IDs, mediaFile.SpecialName)
+	} else if mediaFile.Absolute {
+		result, err = client.SearchTVShowAbsolute(mediaFile.SanitizedName, mediaFile.IDs, episode)
+	} else {
+		result, err = client.SearchTVShow(mediaFile.SanitizedName, mediaFile.IDs, mediaFile.Season, episode)
+	}
 	if err != nil {
 		log.Printf("Error while media search on %s : %s. Sanitized name was : %s", mediaFile.Filename, err.Error(), mediaFile.SanitizedName)
 		pkg.AppendJobLog(fmt.Sprintf("Error while media search on %s : %s. Sanitized name was : %s", mediaFile.Filename, err.Error(), mediaFile.SanitizedName))
-		return pkg.TVEpisode{}, false
+		return pkg.TVEpisode{}, err
 	}
-	return result, true
+	return result, nil
 }
 
 // processMovies moves the media files to the destination directory path provided as argument.
@@ -334,30 +509,35 @@ func (s *MovieScanner) processMovies(movieList *pkg.AtomicMovieList, destination
 	for mediaFile, media := range movieList.GetAll() {
 		now = time.Now()
 		var source = path.Join(mediaFile.Path, mediaFile.Filename)
-		err := s.mediaRepository.IndexMovie(media, source, s.destination)
+		if isDuplicateSource(s.mediaRepository, source, mediaFile.ContentHash) {
+			recordOutcome(s.mediaRepository, source, mediaFile.Disc, mediaFile.MatchHints(), repository.SourceFileIndexed, nil)
+			removeSource(source, mediaFile.Disc != nil, mediaFile.Subtitles)
+			continue
+		}
+		folder, err := s.mediaRepository.IndexMovie(media, mediaFile.Release, mediaFile.Subtitles, mediaFile.ContentHash, mediaFile.Input(), s.destination)
+		if isDuplicateKept(s.mediaRepository, source, mediaFile.Disc, mediaFile.MatchHints(), mediaFile.Subtitles, err) {
+			continue
+		}
 		if err != nil {
+			recordOutcome(s.mediaRepository, source, mediaFile.Disc, mediaFile.MatchHints(), repository.SourceFileIndexFailed, err)
 			log.Printf("Failed to index %s to %s : %s", source, s.destination, err.Error())
 			pkg.AppendJobLog(fmt.Sprintf("Failed to index %s to %s : %s", source, s.destination, err.Error()))
 			return err
 		}
-		log.Printf("Processed %s - %s %s. Took %v", mediaFile.Filename, media.Name, media.Year(), time.Since(now))
-		pkg.AppendJobLog(fmt.Sprintf("Processed %-60s - %s %s. Took %v", mediaFile.Filename, media.Name, media.Year(), time.Since(now)))
+		recordOutcome(s.mediaRepository, source, mediaFile.Disc, mediaFile.MatchHints(), repository.SourceFileIndexed, nil)
+		log.Printf("Processed %s - %s %s (%s). Took %v", mediaFile.Filename, media.Name, media.Year(), mediaFile.Release, time.Since(now))
+		pkg.AppendJobLog(fmt.Sprintf("Processed %-60s - %s %s (%s). Took %v", mediaFile.Filename, media.Name, media.Year(), mediaFile.Release, time.Since(now)))
 
-		go func(mediaFile pkg.MovieFile, media pkg.Movie, destination string) {
-			log.Printf("Removing %s", source)
-			pkg.AppendJobLog(fmt.Sprintf("Removing %s", source))
-			err = os.Remove(source)
-			if err != nil {
-				log.Printf("Failed to remove %s : %s", source, err.Error())
-				pkg.AppendJobLog(fmt.Sprintf("Failed to remove %s : %s", source, err.Error()))
-			}
+		go func(mediaFile *pkg.MovieFile, media pkg.Movie, destination, folder string) {
+			removeSource(source, mediaFile.Disc != nil, mediaFile.Subtitles)
 			// Upload destination to S3
 			now = time.Now()
 			log.Printf("Uploading movie %d to S3...", media.ID)
 			pkg.AppendJobLog(fmt.Sprintf("Uploading movie %d to S3...", media.ID))
+			// The trailing slash keeps the other versions of the movie, whose folders start with its ID
 			err = s.objectStorage.UploadMediaFiles(
-				path.Join("movies", strconv.Itoa(media.ID)),
-				path.Join(destination, strconv.Itoa(media.ID)),
+				path.Join("movies", folder)+"/",
+				path.Join(destination, folder),
 			)
 			if err != nil {
 				log.Printf("Failed to upload %s to S3 : %s", destination, err.Error())
@@ -367,21 +547,42 @@ func (s *MovieScanner) processMovies(movieList *pkg.AtomicMovieList, destination
 				pkg.AppendJobLog(fmt.Sprintf("Uploaded %s to S3. Took %v", destination, time.Since(now)))
 			}
 			// Remove destination from local
-			log.Printf("Removing %s from local storage", path.Join(destination, strconv.Itoa(media.ID)))
-			pkg.AppendJobLog(fmt.Sprintf("Removing %s from local storage", path.Join(destination, strconv.Itoa(media.ID))))
-			err = os.RemoveAll(path.Join(destination, strconv.Itoa(media.ID)))
+			log.Printf("Removing %s from local storage", path.Join(destination, folder))
+			pkg.AppendJobLog(fmt.Sprintf("Removing %s from local storage", path.Join(destination, folder)))
+			err = os.RemoveAll(path.Join(destination, folder))
 			if err != nil {
-				log.Printf("Failed to remove %s : %s", path.Join(destination, strconv.Itoa(media.ID)), err.Error())
-				pkg.AppendJobLog(fmt.Sprintf("Failed to remove %s : %s", path.Join(destination, strconv.Itoa(media.ID)), err.Error()))
+				log.Printf("Failed to remove %s : %s", path.Join(destination, folder), err.Error())
+				pkg.AppendJobLog(fmt.Sprintf("Failed to remove %s : %s", path.Join(destination, folder), err.Error()))
 			} else {
-				log.Printf("Removed %s from local storage", path.Join(destination, strconv.Itoa(media.ID)))
-				pkg.AppendJobLog(fmt.Sprintf("Removed %s from local storage", path.Join(destination, strconv.Itoa(media.ID))))
+				log.Printf("Removed %s from local storage", path.Join(destination, folder))
+				pkg.AppendJobLog(fmt.Sprintf("Removed %s from local storage", path.Join(destination, folder)))
 			}
-		}(mediaFile, media, destination)
+		}(mediaFile, media, destination, folder)
 	}
 	return nil
 }
 
+// removeSource removes a processed media file and its sidecar subtitles from the source folder.
+// Only a disc folder is removed with its content, a plain file being removed on its own.
+func removeSource(source string, isDisc bool, subtitles []pkg.SubtitleFile) {
+	var sources = []string{source}
+	for _, subtitle := range subtitles {
+		sources = append(sources, path.Join(subtitle.Path, subtitle.Filename))
+	}
+	for i, source := range sources {
+		log.Printf("Removing %s", source)
+		pkg.AppendJobLog(fmt.Sprintf("Removing %s", source))
+		var remove = os.Remove
+		if i == 0 && isDisc {
+			remove = os.RemoveAll
+		}
+		if err := remove(source); err != nil {
+			log.Printf("Failed to remove %s : %s", source, err.Error())
+			pkg.AppendJobLog(fmt.Sprintf("Failed to remove %s : %s", source, err.Error()))
+		}
+	}
+}
+
 // processTVEpisodes moves the media files to the destination directory path provided as argument.
 // It returns an error if the destination directory does not exist or if there was an error while moving the file.
 func (s *TVScanner) processTVEpisodes(tvList *pkg.AtomicTVEpisodeList, destination string) error {
@@ -390,31 +591,50 @@ func (s *TVScanner) processTVEpisodes(tvList *pkg.AtomicTVEpisodeList, destinati
 		return errors.New("destination directory does not exists")
 	}
 	var now time.Time
-	for mediaFile, media := range tvList.GetAll() {
+	for mediaFile, episodes := range tvList.GetAll() {
 		now = time.Now()
+		// A multi-episode file is stored once, in the folder of its first episode
+		media := episodes[0]
 		var source = path.Join(mediaFile.Path, mediaFile.Filename)
-		err := s.mediaRepository.IndexTvEpisode(media, source, s.destination)
+		if isDuplicateSource(s.mediaRepository, source, mediaFile.ContentHash) {
+			recordOutcome(s.mediaRepository, source, nil, mediaFile.MatchHints(), repository.SourceFileIndexed, nil)
+			removeSource(source, false, mediaFile.Subtitles)
+			continue
+		}
+		replaced, err := s.mediaRepository.IndexTvEpisodes(episodes, mediaFile.Release, mediaFile.Subtitles, mediaFile.ContentHash, source, s.destination)
+		if isDuplicateKept(s.mediaRepository, source, nil, mediaFile.MatchHints(), mediaFile.Subtitles, err) {
+			continue
+		}
 		if err != nil {
+			recordOutcome(s.mediaRepository, source, nil, mediaFile.MatchHints(), repository.SourceFileIndexFailed, err)
 			log.Printf("Failed to index %s to %s : %s", source, s.destination, err.Error())
 			pkg.AppendJobLog(fmt.Sprintf("Failed to index %s to %s : %s", source, s.destination, err.Error()))
 			return err
 		}
-		log.Printf("Processed %-60s - %s - %s s%02de%02d\nTook %s", mediaFile.Filename, media.TvShowName, media.Year(), mediaFile.Season, mediaFile.Episode, time.Since(now))
-		pkg.AppendJobLog(fmt.Sprintf("Processed %-60s - %s - %s\nTook %s", mediaFile.Filename, media.TvShowName, media.Year(), time.Since(now)))
-
-		go func(mediaFile pkg.TVShowFile, media pkg.TVEpisode, destination string) {
-			log.Printf("Removing %s", source)
-			pkg.AppendJobLog(fmt.Sprintf("Removing %s", source))
-			err = os.Remove(source)
-			if err != nil {
-				log.Printf("Failed to remove %s : %s", source, err.Error())
-				pkg.AppendJobLog(fmt.Sprintf("Failed to remove %s : %s", source, err.Error()))
+		recordOutcome(s.mediaRepository, source, nil, mediaFile.MatchHints(), repository.SourceFileIndexed, nil)
+		log.Printf("Processed %-60s - %s - %s s%02de%02d (%d episodes)\nTook %s", mediaFile.Filename, media.TvShowName, media.Year(), mediaFile.Season, mediaFile.Episode, len(episodes), time.Since(now))
+		pkg.AppendJobLog(fmt.Sprintf("Processed %-60s - %s - %s (%d episodes)\nTook %s", mediaFile.Filename, media.TvShowName, media.Year(), len(episodes), time.Since(now)))
+
+		go func(mediaFile *pkg.TVShowFile, media pkg.TVEpisode, destination string, replaced []string) {
+			removeSource(source, false, mediaFile.Subtitles)
+			// The replaced files stored in the folder of another episode are not overwritten by the upload
+			for _, folder := range replaced {
+				if folder == strconv.Itoa(media.ID) {
+					continue
+				}
+				log.Printf("Removing replaced folder %s from S3", folder)
+				pkg.AppendJobLog(fmt.Sprintf("Removing replaced folder %s from S3", folder))
+				if err := s.objectStorage.DeleteMediaFiles(path.Join("tv-shows", folder) + "/"); err != nil {
+					log.Printf("Failed to remove %s from S3 : %s", folder, err.Error())
+					pkg.AppendJobLog(fmt.Sprintf("Failed to remove %s from S3 : %s", folder, err.Error()))
+				}
 			}
 			// Upload destination to S3
 			log.Printf("Uploading episode %d to S3...", media.ID)
 			pkg.AppendJobLog(fmt.Sprintf("Uploading episode %d to S3...", media.ID))
+			// The trailing slash keeps the episodes whose ID starts with this one
 			err = s.objectStorage.UploadMediaFiles(
-				path.Join("tv-shows", strconv.Itoa(media.ID)),
+				path.Join("tv-shows", strconv.Itoa(media.ID))+"/",
 				path.Join(destination, strconv.Itoa(media.ID)),
 			)
 			if err != nil {
@@ -429,7 +649,7 @@ func (s *TVScanner) processTVEpisodes(tvList *pkg.AtomicTVEpisodeList, destinati
 				log.Printf("Failed to remove %s : %s", path.Join(destination, strconv.Itoa(media.ID)), err.Error())
 				pkg.AppendJobLog(fmt.Sprintf("Failed to remove %s : %s", path.Join(destination, strconv.Itoa(media.ID)), err.Error()))
 			}
-		}(mediaFile, media, destination)
+		}(mediaFile, media, destination, replaced)
 	}
 	return nil
 }
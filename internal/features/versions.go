@@ -0,0 +1,80 @@
+package features
+
+import (
+	"fmt"
+	"github.com/bingemate/media-indexer/internal/repository"
+	"github.com/bingemate/media-indexer/pkg"
+	"sort"
+)
+
+// MovieVersionsLister lists the versions of the movies in the library, for the services streaming them.
+type MovieVersionsLister struct {
+	mediaRepository *repository.MediaRepository // Media repository object to retrieve the versions of a movie.
+}
+
+// MovieVersion represents a file of a movie in the library, told apart from the other files of the movie by its label.
+type MovieVersion struct {
+	MediaFileID   string `json:"mediaFileId" example:"0b7c5c39-5d5f-4c8e-9a3c-1f0d1c2b3a4e"`
+	Label         string `json:"label" example:"Director's Cut 2160p"`
+	StorageFolder string `json:"storageFolder" example:"27205-director-s-cut-2160p"` // Folder of the version under "movies/" in the object storage
+	Main          bool   `json:"main" example:"false"`                               // Whether it is the media file of the movie itself
+	Edition       string `json:"edition" example:"Director's Cut"`
+	Resolution    string `json:"resolution" example:"2160p"`
+	Source        string `json:"source" example:"BluRay"`
+}
+
+// MovieVersionsReport holds the versions of a movie in the library.
+type MovieVersionsReport struct {
+	MovieID   int            `json:"movieId" example:"27205"`
+	MovieName string         `json:"movieName" example:"Inception"`
+	Versions  []MovieVersion `json:"versions"` // Versions of the movie, the main one first
+}
+
+// NewMovieVersionsLister returns a new instance of MovieVersionsLister.
+func NewMovieVersionsLister(mediaRepository *repository.MediaRepository) *MovieVersionsLister {
+	return &MovieVersionsLister{
+		mediaRepository: mediaRepository,
+	}
+}
+
+// ListMovieVersions lists the versions of a movie in the library, failing with pkg.ErrNotFound if the movie is not in the library.
+func (l *MovieVersionsLister) ListMovieVersions(movieID int) (*MovieVersionsReport, error) {
+	movie, versions, err := l.mediaRepository.FindMovieVersions(movieID)
+	if err != nil {
+		return nil, err
+	}
+	if movie == nil {
+		return nil, fmt.Errorf("movie %d is not in the library: %w", movieID, pkg.ErrNotFound)
+	}
+
+	var report = &MovieVersionsReport{
+		MovieID:   movie.ID,
+		MovieName: movie.Name,
+		Versions:  make([]MovieVersion, 0, len(versions)),
+	}
+	for _, version := range versions {
+		info, err := l.mediaRepository.FindMediaFileInfo(version.MediaFileID)
+		if err != nil {
+			return nil, err
+		}
+		movieVersion := MovieVersion{
+			MediaFileID:   version.MediaFileID,
+			Label:         version.Label,
+			StorageFolder: version.StorageFolder,
+			Main:          version.MediaFileID == *movie.MediaFileID,
+		}
+		if info != nil {
+			movieVersion.Edition = info.Edition
+			movieVersion.Resolution = info.Resolution
+			movieVersion.Source = info.Source
+		}
+		report.Versions = append(report.Versions, movieVersion)
+	}
+	sort.SliceStable(report.Versions, func(i, j int) bool {
+		if report.Versions[i].Main != report.Versions[j].Main {
+			return report.Versions[i].Main
+		}
+		return report.Versions[i].Label < report.Versions[j].Label
+	})
+	return report, nil
+}
@@ -0,0 +1,159 @@
+package features
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SourceWatcher watches a source directory and scans the subtrees where files were created or written, once they have settled.
+// A subtree is a folder directly under the source directory, or the source directory itself for the files at its root.
+type SourceWatcher struct {
+	source  string                     // Source directory to watch.
+	scan    func(subtree string) error // Scans a subtree of the source directory, failing if a job is already running.
+	delay   time.Duration              // Time without event after which a subtree is scanned.
+	watcher *fsnotify.Watcher
+	lock    sync.Mutex
+	pending map[string]time.Time // Time of the last event of the subtrees waiting for a scan.
+}
+
+// WatchSources starts a watcher on the source directories of the movie and TV scanners.
+// The delay should be at least the settle period of the files, otherwise they are skipped as still being written.
+func WatchSources(delay time.Duration, movieScanner *MovieScanner, tvScanner *TVScanner) {
+	watch(movieScanner.source, movieScanner.ScanMovieSubtree, delay)
+	watch(tvScanner.source, tvScanner.ScanTVSubtree, delay)
+}
+
+func watch(source string, scan func(subtree string) error, delay time.Duration) {
+	watcher, err := NewSourceWatcher(source, scan, delay)
+	if err != nil {
+		log.Printf("Disabling watcher of %s: %v", source, err)
+		return
+	}
+	watcher.Start()
+	log.Printf("Watching %s for new media", source)
+}
+
+// NewSourceWatcher returns a watcher of the given source directory and its subfolders, triggering scan after delay without event.
+func NewSourceWatcher(source string, scan func(subtree string) error, delay time.Duration) (*SourceWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	sourceWatcher := &SourceWatcher{
+		source:  filepath.Clean(source),
+		scan:    scan,
+		delay:   delay,
+		watcher: watcher,
+		pending: make(map[string]time.Time),
+	}
+	if err = sourceWatcher.addFolder(sourceWatcher.source); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return sourceWatcher, nil
+}
+
+// Start handles the filesystem events and triggers the scans in the background
+func (w *SourceWatcher) Start() {
+	go w.watch()
+	go w.flush()
+}
+
+// watch records the subtrees of the created and written files, watching the created folders as well since fsnotify is not recursive
+func (w *SourceWatcher) watch() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err = w.addFolder(event.Name); err != nil {
+						log.Printf("Failed to watch %s: %v", event.Name, err)
+					}
+				}
+			}
+			w.lock.Lock()
+			w.pending[w.subtree(event.Name)] = time.Now()
+			w.lock.Unlock()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Watcher error on %s: %v", w.source, err)
+		}
+	}
+}
+
+// flush scans the subtrees left without event for the delay, keeping them pending while another job is running
+func (w *SourceWatcher) flush() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		// Let the scheduled scan and the running job finish first
+		if !schedulerMutex.TryLock() {
+			continue
+		}
+		if IsJobRunning() {
+			schedulerMutex.Unlock()
+			continue
+		}
+		w.lock.Lock()
+		for subtree, lastEvent := range w.pending {
+			if time.Since(lastEvent) < w.delay {
+				continue
+			}
+			if _, err := os.Stat(subtree); err != nil {
+				delete(w.pending, subtree)
+				continue
+			}
+			if err := w.scan(subtree); err != nil {
+				continue
+			}
+			log.Printf("Scanning %s after changes", subtree)
+			delete(w.pending, subtree)
+			// The other subtrees wait for this job to finish
+			break
+		}
+		w.lock.Unlock()
+		schedulerMutex.Unlock()
+	}
+}
+
+// addFolder watches a folder and its subfolders
+func (w *SourceWatcher) addFolder(folder string) error {
+	return filepath.WalkDir(folder, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return w.watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// subtree returns the folder directly under the source directory holding the given path, the source directory for its own files
+func (w *SourceWatcher) subtree(path string) string {
+	relativePath, err := filepath.Rel(w.source, path)
+	if err != nil || relativePath == "." {
+		return w.source
+	}
+	folders := strings.Split(relativePath, string(filepath.Separator))
+	if len(folders) == 1 {
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return w.source
+		}
+	}
+	return filepath.Join(w.source, folders[0])
+}
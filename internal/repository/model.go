@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"github.com/bingemate/media-go-pkg/repository"
+	"gorm.io/gorm"
+	"time"
+)
+
+// MovieCollection is a TMDB collection grouping several movies (e.g. a saga)
+type MovieCollection struct {
+	ID           int       `gorm:"primaryKey"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+	Name         string
+	PosterPath   string
+	BackdropPath string
+}
+
+// MovieMetadata holds the TMDB details of a movie that are not part of the shared repository.Movie model
+type MovieMetadata struct {
+	MovieID          int              `gorm:"primaryKey"`
+	Movie            repository.Movie `gorm:"reference:MovieID;constraint:OnDelete:CASCADE;"`
+	CreatedAt        time.Time        `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time        `gorm:"autoUpdateTime"`
+	OriginalTitle    string
+	Overview         string
+	Runtime          int // Runtime in minutes
+	OriginalLanguage string
+	PosterPath       string
+	BackdropPath     string
+	ImdbID           string           `gorm:"index"`
+	CollectionID     *int             `gorm:"index"`
+	Collection       *MovieCollection `gorm:"reference:CollectionID;constraint:OnDelete:SET NULL;"`
+}
+
+// TvShowMetadata holds the TMDB details of a TV show that are not part of the shared repository.TvShow model
+type TvShowMetadata struct {
+	TvShowID     int               `gorm:"primaryKey"`
+	TvShow       repository.TvShow `gorm:"reference:TvShowID;constraint:OnDelete:CASCADE;"`
+	CreatedAt    time.Time         `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time         `gorm:"autoUpdateTime"`
+	OriginalName string
+	Overview     string
+	Status       string // TMDB status of the show (e.g. Ended, Returning Series, Canceled)
+	Network      string
+	SeasonCount  int
+	EpisodeCount int
+	PosterPath   string
+	BackdropPath string
+}
+
+// TvSeason is a season of a TV show as listed by TMDB, whether or not its episodes are in the library
+type TvSeason struct {
+	ID           int               `gorm:"primaryKey"`
+	CreatedAt    time.Time         `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time         `gorm:"autoUpdateTime"`
+	TvShowID     int               `gorm:"not null;uniqueIndex:idx_tv_season_number"`
+	TvShow       repository.TvShow `gorm:"reference:TvShowID;constraint:OnDelete:CASCADE;"`
+	NbSeason     int               `gorm:"uniqueIndex:idx_tv_season_number"`
+	Name         string
+	AirDate      *time.Time `gorm:"type:date"`
+	EpisodeCount int
+	PosterPath   string
+}
+
+// MediaFileInfo holds the release attributes parsed from the name of the source file of a media file, and its probed quality
+type MediaFileInfo struct {
+	MediaFileID    string               `gorm:"type:uuid;primaryKey"`
+	MediaFile      repository.MediaFile `gorm:"reference:MediaFileID;constraint:OnDelete:CASCADE;"`
+	CreatedAt      time.Time            `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time            `gorm:"autoUpdateTime"`
+	SourceFilename string
+	Resolution     string // e.g. 2160p, 1080p, 720p
+	Source         string // e.g. BluRay, Remux, WEB-DL, HDTV
+	VideoCodec     string
+	AudioCodec     string
+	HDR            bool
+	Edition        string
+	ReleaseGroup   string
+	Languages      string // Comma separated language tags (e.g. MULTI,VFF)
+	ContentHash    string `gorm:"index"` // Partial hash of the source file content, see pkg.ContentHash
+	Width          int    // Probed video width in pixels
+	Height         int    // Probed video height in pixels
+	Bitrate        int64  // Probed overall bitrate in bits per second
+	ProbedCodec    string // Probed video codec (e.g. HEVC), VideoCodec being the one of the release name
+}
+
+// MovieVersion is a file of a movie, a movie having several versions when they differ by edition or quality (e.g. Director's Cut, 2160p).
+// The media file of the shared repository.Movie model is its main version, stored in the folder named after the movie ID.
+type MovieVersion struct {
+	MediaFileID   string               `gorm:"type:uuid;primaryKey"`
+	MediaFile     repository.MediaFile `gorm:"reference:MediaFileID;constraint:OnDelete:CASCADE;"`
+	CreatedAt     time.Time            `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time            `gorm:"autoUpdateTime"`
+	MovieID       int                  `gorm:"not null;uniqueIndex:idx_movie_version_label"`
+	Movie         repository.Movie     `gorm:"reference:MovieID;constraint:OnDelete:CASCADE;"`
+	Label         string               `gorm:"uniqueIndex:idx_movie_version_label"` // Edition and resolution of the version, see pkg.VersionLabel
+	StorageFolder string               // Folder of the version in the destination folder, and under "movies/" in the object storage
+}
+
+// SubtitleInfo holds the flags of a subtitle track which are not part of the shared subtitle model
+type SubtitleInfo struct {
+	SubtitleID     string              `gorm:"type:uuid;primaryKey"`
+	Subtitle       repository.Subtitle `gorm:"reference:SubtitleID;constraint:OnDelete:CASCADE;"`
+	CreatedAt      time.Time           `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time           `gorm:"autoUpdateTime"`
+	SourceFilename string              // Name of the sidecar subtitle file, empty for a track of the video file
+	Forced         bool
+	SDH            bool
+}
+
+// Outcomes of the last scan of a source file
+const (
+	SourceFileNotFound      = "not_found"      // No match found, skipped until the file or its match hints change
+	SourceFileProviderError = "provider_error" // Lookup failed because of the provider, retried on the next scan
+	SourceFileIndexFailed   = "index_failed"   // Matched but failed to be indexed, retried on the next scan
+	SourceFileIndexed       = "indexed"        // Indexed, skipped until the file changes
+	SourceFileSample        = "sample"         // Found to be a sample by its duration, skipped until the file changes
+	SourceFileDuplicate     = "duplicate"      // Left in place as the duplicate policy keeps both files of a TV episode, skipped until the file changes
+)
+
+// SourceFile is an entry of the index of the files seen by the scans, so that a scan only processes new or changed files
+type SourceFile struct {
+	Path      string    `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Size      int64
+	ModTime   int64  // Modification time in nanoseconds, kept as an integer to be compared exactly
+	Hints     string // Match hints of the file when it was scanned, e.g. its parsed title and ID tags
+	Outcome   string
+	Error     string // Error of the last lookup or indexing, if any
+}
+
+// Migrate creates the tables owned by the indexer, on top of the shared ones migrated by repository.Migrate
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&MovieCollection{},
+		&MovieMetadata{},
+		&TvShowMetadata{},
+		&TvSeason{},
+		&MediaFileInfo{},
+		&MovieVersion{},
+		&SubtitleInfo{},
+		&SourceFile{},
+	)
+}
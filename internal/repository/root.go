@@ -7,138 +7,203 @@ import (
 	"github.com/bingemate/media-go-pkg/transcoder"
 	"github.com/bingemate/media-indexer/pkg"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"io/fs"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// ErrDuplicateKept is returned when the file indexed for a movie or an episode is kept instead of the new one by the duplicate policy
+var ErrDuplicateKept = errors.New("the indexed file is kept by the duplicate policy")
+
+// ErrDuplicateVersion is returned when the duplicate policy asks to keep both files of a TV episode, an episode being linked to a single file
+var ErrDuplicateVersion = errors.New("keeping several versions of a media is not supported")
+
+var versionFolderRegex = regexp.MustCompile(`[^a-z0-9]+`) // regex to match the characters replaced in the folder of a movie version
+
 type MediaRepository struct {
 	db               *gorm.DB
 	introFilePath    string
 	intro219FilePath string
+	duplicatePolicy  pkg.DuplicatePolicy // Tells whether a new file replaces the one indexed for the same media
 }
 
-func NewMediaRepository(db *gorm.DB, introFilePath string, intro219FilePath string) *MediaRepository {
+func NewMediaRepository(db *gorm.DB, introFilePath string, intro219FilePath string, duplicatePolicy pkg.DuplicatePolicy) *MediaRepository {
 	if db == nil {
 		log.Fatal("db is nil")
 	}
-	return &MediaRepository{db: db, introFilePath: introFilePath, intro219FilePath: intro219FilePath}
+	return &MediaRepository{db: db, introFilePath: introFilePath, intro219FilePath: intro219FilePath, duplicatePolicy: duplicatePolicy}
 }
 
-func (r *MediaRepository) IndexMovie(movie pkg.Movie, fileSource, destinationPath string) error {
+// IndexMovie transcodes a movie file and indexes it as a version of the movie, returning the folder it was transcoded to in the destination folder.
+// A file which doesn't replace an indexed version is stored as a new version, its media file being only linked to the movie by a MovieVersion.
+func (r *MediaRepository) IndexMovie(movie pkg.Movie, release pkg.Release, subtitles []pkg.SubtitleFile, contentHash string, fileSource, destinationPath string) (string, error) {
 	log.Printf("Indexing movie %s", movie.Name)
 	pkg.AppendJobLog(fmt.Sprintf("Indexing movie %s", movie.Name))
 	releaseDate, err := time.Parse("2006-01-02", movie.ReleaseDate)
 	if err != nil {
-		return err
+		return "", err
 	}
 	mediaData, err := pkg.RetrieveMediaData(fileSource)
 	if err != nil {
-		return err
+		return "", err
 	}
+	quality := pkg.NewQuality(mediaData, release)
+	label := pkg.VersionLabel(release.Edition, quality)
 
-	err = r.handleDuplicatedMovie(movie.ID, destinationPath)
+	folder, isMainVersion, err := r.handleDuplicatedMovie(movie.ID, label, destinationPath, quality)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Transcode movie here and retrieve file destination infos
-	response, err := transcoder.ProcessFileTranscode(fileSource, r.introFilePath, r.intro219FilePath, strconv.Itoa(movie.ID), destinationPath, "10", "1280:720", "1920:816")
+	response, err := transcoder.ProcessFileTranscode(fileSource, r.introFilePath, r.intro219FilePath, folder, destinationPath, "10", "1280:720", "1920:816")
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	folderSize := getFolderSize(path.Join(destinationPath, strconv.Itoa(movie.ID)))
+	sidecarSubtitles, convertedSubtitles := r.convertSubtitles(subtitles, path.Join(destinationPath, folder))
+
+	folderSize := getFolderSize(path.Join(destinationPath, folder))
 
 	alreadyInDB, err := r.findMovie(movie.ID)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return err
+		return "", err
 	}
 
-	movieEntity := repository.Movie{
-		ID:          movie.ID,
-		Name:        movie.Name,
-		ReleaseDate: releaseDate,
-		MediaFile:   r.extractMediaFile(&mediaData, folderSize, &response),
-	}
+	mediaFile := r.extractMediaFile(&mediaData, folderSize, &response)
+	mediaFile.Subtitles = append(mediaFile.Subtitles, sidecarSubtitles...)
+	if isMainVersion {
+		movieEntity := repository.Movie{
+			ID:          movie.ID,
+			Name:        movie.Name,
+			ReleaseDate: releaseDate,
+			MediaFile:   mediaFile,
+		}
 
-	if alreadyInDB != nil {
-		movieEntity.CreatedAt = alreadyInDB.CreatedAt
-	}
+		if alreadyInDB != nil {
+			movieEntity.CreatedAt = alreadyInDB.CreatedAt
+		}
 
-	db := r.db.Save(&movieEntity)
-	if db.Error != nil {
-		return db.Error
+		db := r.db.Save(&movieEntity)
+		if db.Error != nil {
+			return "", db.Error
+		}
+		movieEntity.Categories = *r.extractCategories(&movie.Categories)
+		db = r.db.Save(&movieEntity)
+		if db.Error != nil {
+			return "", db.Error
+		}
+	} else {
+		db := r.db.Create(mediaFile)
+		if db.Error != nil {
+			return "", db.Error
+		}
 	}
-	movieEntity.Categories = *r.extractCategories(&movie.Categories)
-	db = r.db.Save(&movieEntity)
-	if db.Error != nil {
-		return db.Error
+	err = r.saveMovieVersion(movie.ID, mediaFile.ID, label, folder)
+	if err != nil {
+		return "", err
 	}
-	return nil
-}
-
-func (r *MediaRepository) IndexTvEpisode(tvEpisode pkg.TVEpisode, fileSource, destinationPath string) error {
-	log.Printf("Indexing tv show %s - S%02dE%02d", tvEpisode.TvShowName, tvEpisode.Season, tvEpisode.Episode)
-	pkg.AppendJobLog(fmt.Sprintf("Indexing tv show %s - S%02dE%02d", tvEpisode.TvShowName, tvEpisode.Season, tvEpisode.Episode))
-	releaseDate, err := time.Parse("2006-01-02", tvEpisode.TvReleaseDate)
+	err = r.saveMediaFileInfo(mediaFile.ID, path.Base(fileSource), contentHash, &release, quality)
 	if err != nil {
-		return err
+		return "", err
 	}
-	episodeReleaseDate, err := time.Parse("2006-01-02", tvEpisode.ReleaseDate)
+	err = r.saveSubtitleInfos(mediaFile.ID, sidecarSubtitles, convertedSubtitles)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return folder, r.saveMovieMetadata(&movie)
+}
+
+// IndexTvEpisodes transcodes a TV show file and indexes the episodes it holds.
+// A multi-episode file is transcoded once, in the folder of its first episode, and its media file is linked to every episode.
+// It returns the folders of the media files it replaced in the destination folder.
+func (r *MediaRepository) IndexTvEpisodes(tvEpisodes []pkg.TVEpisode, release pkg.Release, subtitles []pkg.SubtitleFile, contentHash string, fileSource, destinationPath string) ([]string, error) {
+	if len(tvEpisodes) == 0 {
+		return nil, errors.New("no episode to index")
+	}
+	first := tvEpisodes[0]
+	for _, tvEpisode := range tvEpisodes {
+		log.Printf("Indexing tv show %s - S%02dE%02d", tvEpisode.TvShowName, tvEpisode.Season, tvEpisode.Episode)
+		pkg.AppendJobLog(fmt.Sprintf("Indexing tv show %s - S%02dE%02d", tvEpisode.TvShowName, tvEpisode.Season, tvEpisode.Episode))
+	}
+	releaseDate := parseShowReleaseDate(first.TvReleaseDate)
 	mediaData, err := pkg.RetrieveMediaData(fileSource)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	tvShowEntity, err := r.handleTvShow(tvEpisode.TvShowName, tvEpisode.TvShowID, releaseDate, &tvEpisode.Categories)
+	quality := pkg.NewQuality(mediaData, release)
+
+	replaced, err := r.handleDuplicatedEpisodes(tvEpisodes, destinationPath, quality)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	err = r.handleDuplicatedEpisode(tvEpisode.ID, destinationPath)
+	tvShowEntity, err := r.handleTvShow(first.TvShowName, first.TvShowID, releaseDate, &first.Categories)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Transcode episode here and retrieve file destination infos
-	response, err := transcoder.ProcessFileTranscode(fileSource, r.introFilePath, r.intro219FilePath, strconv.Itoa(tvEpisode.ID), destinationPath, "10", "1280:720", "1920:816")
+	response, err := transcoder.ProcessFileTranscode(fileSource, r.introFilePath, r.intro219FilePath, strconv.Itoa(first.ID), destinationPath, "10", "1280:720", "1920:816")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	folderSize := getFolderSize(path.Join(destinationPath, strconv.Itoa(tvEpisode.ID)))
+	sidecarSubtitles, convertedSubtitles := r.convertSubtitles(subtitles, path.Join(destinationPath, strconv.Itoa(first.ID)))
 
-	alreadyInDB, err := r.findEpisode(tvEpisode.ID)
-	if err != nil {
-		return err
-	}
+	folderSize := getFolderSize(path.Join(destinationPath, strconv.Itoa(first.ID)))
+	mediaFile := r.extractMediaFile(&mediaData, folderSize, &response)
+	mediaFile.Subtitles = append(mediaFile.Subtitles, sidecarSubtitles...)
 
-	episodeEntity := repository.Episode{
-		ID:          tvEpisode.ID,
-		TvShow:      *tvShowEntity,
-		Name:        tvEpisode.EpisodeName,
-		NbEpisode:   tvEpisode.Episode,
-		NbSeason:    tvEpisode.Season,
-		ReleaseDate: episodeReleaseDate,
-		MediaFile:   r.extractMediaFile(&mediaData, folderSize, &response),
-	}
+	for i, tvEpisode := range tvEpisodes {
+		// Specials are often listed without air date
+		var episodeReleaseDate time.Time
+		if tvEpisode.ReleaseDate != "" {
+			episodeReleaseDate, err = time.Parse("2006-01-02", tvEpisode.ReleaseDate)
+			if err != nil {
+				return nil, err
+			}
+		}
+		alreadyInDB, err := r.findEpisode(tvEpisode.ID)
+		if err != nil {
+			return nil, err
+		}
 
-	if alreadyInDB != nil {
-		episodeEntity.CreatedAt = alreadyInDB.CreatedAt
-	}
+		episodeEntity := repository.Episode{
+			ID:          tvEpisode.ID,
+			TvShow:      *tvShowEntity,
+			Name:        tvEpisode.EpisodeName,
+			NbEpisode:   tvEpisode.Episode,
+			NbSeason:    tvEpisode.Season,
+			ReleaseDate: episodeReleaseDate,
+		}
+		// The media file is created with the first episode, the following ones only reference it
+		if i == 0 {
+			episodeEntity.MediaFile = mediaFile
+		} else {
+			episodeEntity.MediaFileID = &mediaFile.ID
+		}
 
-	db := r.db.Save(&episodeEntity)
-	if db.Error != nil {
-		return db.Error
+		if alreadyInDB != nil {
+			episodeEntity.CreatedAt = alreadyInDB.CreatedAt
+		}
+
+		db := r.db.Save(&episodeEntity)
+		if db.Error != nil {
+			return nil, db.Error
+		}
 	}
-	return nil
+	err = r.saveMediaFileInfo(mediaFile.ID, path.Base(fileSource), contentHash, &release, quality)
+	if err != nil {
+		return nil, err
+	}
+	return replaced, r.saveSubtitleInfos(mediaFile.ID, sidecarSubtitles, convertedSubtitles)
 }
 
 func (r *MediaRepository) extractMediaFile(mediaData *pkg.MediaData, size int64, transcoderResponse *transcoder.TranscodeResponse) *repository.MediaFile {
@@ -162,6 +227,38 @@ func (r *MediaRepository) extractSubtitles(pkgSubtitles *[]pkg.SubtitleData, tra
 	return &subtitles
 }
 
+// convertSubtitles converts the sidecar subtitles of a media file to WebVTT in its output folder, shifted by the intro duration.
+// It returns the subtitles converted along with their sidecar files; a subtitle failing to convert is skipped,
+// as it must not prevent the video from being indexed.
+func (r *MediaRepository) convertSubtitles(subtitleFiles []pkg.SubtitleFile, outputFolder string) ([]repository.Subtitle, []pkg.SubtitleFile) {
+	var subtitles = make([]repository.Subtitle, 0, len(subtitleFiles))
+	var converted = make([]pkg.SubtitleFile, 0, len(subtitleFiles))
+	if len(subtitleFiles) == 0 {
+		return subtitles, converted
+	}
+	introDuration, err := pkg.RetrieveMediaDuration(r.introFilePath)
+	if err != nil {
+		log.Printf("Failed to retrieve intro duration, sidecar subtitles are skipped: %v", err)
+		pkg.AppendJobLog(fmt.Sprintf("Failed to retrieve intro duration, sidecar subtitles are skipped: %v", err))
+		return subtitles, converted
+	}
+	for i, subtitleFile := range subtitleFiles {
+		filename := fmt.Sprintf("subtitle_sidecar_%d.vtt", i)
+		err = pkg.ConvertSubtitle(path.Join(subtitleFile.Path, subtitleFile.Filename), path.Join(outputFolder, filename), introDuration)
+		if err != nil {
+			log.Printf("Failed to convert subtitle %s: %v", subtitleFile.Filename, err)
+			pkg.AppendJobLog(fmt.Sprintf("Failed to convert subtitle %s: %v", subtitleFile.Filename, err))
+			continue
+		}
+		subtitles = append(subtitles, repository.Subtitle{
+			Filename: filename,
+			Language: subtitleFile.Language,
+		})
+		converted = append(converted, subtitleFile)
+	}
+	return subtitles, converted
+}
+
 func (r *MediaRepository) extractAudio(audiosData *[]pkg.AudioData, transcoderResponse *transcoder.TranscodeResponse) *[]repository.Audio {
 	var audio = make([]repository.Audio, len(*audiosData))
 	for i, a := range *audiosData {
@@ -188,54 +285,260 @@ func (r *MediaRepository) extractCategories(pkgCategories *[]pkg.Category) *[]re
 	return &categories
 }
 
-func (r *MediaRepository) handleDuplicatedMovie(tmdbID int, destination string) error {
+// handleDuplicatedMovie finds where to store a new version of a movie with the given label, removing the version it replaces if any.
+// With the "both" policy, the new file only competes with the version sharing its label, otherwise with the main version.
+// It returns the folder of the new version in the destination folder, and whether it is the main version of the movie.
+func (r *MediaRepository) handleDuplicatedMovie(tmdbID int, label, destination string, quality pkg.Quality) (string, bool, error) {
 	var movie repository.Movie
 	db := r.db.Joins("MediaFile").Where("movies.id = ?", tmdbID).First(&movie)
 	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
-		return db.Error
+		return "", false, db.Error
 	}
-	if movie.ID == 0 {
-		return nil
+	if movie.ID == 0 || movie.MediaFileID == nil {
+		return strconv.Itoa(tmdbID), true, nil
 	}
 
-	if movie.MediaFileID != nil {
-		log.Printf("Removing duplicated movie %s", movie.Name)
-		pkg.AppendJobLog(fmt.Sprintf("Removing duplicated movie %s", movie.Name))
-		err := r.removeMediaFile(*movie.MediaFileID)
+	versions, recorded, err := r.findMovieVersions(movie)
+	if err != nil {
+		return "", false, err
+	}
+	// The main version of a movie indexed before versions were is recorded when a new file of the movie is indexed
+	if !recorded {
+		mainVersion := versions[len(versions)-1]
+		err = r.saveMovieVersion(mainVersion.MovieID, mainVersion.MediaFileID, mainVersion.Label, mainVersion.StorageFolder)
 		if err != nil {
-			return err
+			return "", false, err
 		}
-		log.Printf("Removing duplicated file %s", movie.MediaFile.Filename)
-		pkg.AppendJobLog(fmt.Sprintf("Removing duplicated file %s", movie.MediaFile.Filename))
-		return os.RemoveAll(path.Join(destination, strconv.Itoa(tmdbID)))
 	}
-	return nil
+	var policy = r.duplicatePolicy
+	var duplicate *MovieVersion
+	for i, version := range versions {
+		var isDuplicate = version.MediaFileID == *movie.MediaFileID
+		if policy == pkg.DuplicatePolicyBoth {
+			isDuplicate = version.Label == label
+		}
+		if isDuplicate {
+			duplicate = &versions[i]
+		}
+	}
+	if duplicate == nil {
+		folder := versionFolder(tmdbID, label)
+		log.Printf("Adding version %s of movie %s", label, movie.Name)
+		pkg.AppendJobLog(fmt.Sprintf("Adding version %s of movie %s", label, movie.Name))
+		return folder, false, os.RemoveAll(path.Join(destination, folder))
+	}
+	// Versions sharing their label are told apart by their quality
+	if policy == pkg.DuplicatePolicyBoth {
+		policy = pkg.DuplicatePolicyBest
+	}
+
+	err = r.resolveDuplicate(fmt.Sprintf("movie %s (%s)", movie.Name, duplicate.Label), duplicate.MediaFileID, quality, policy)
+	if err != nil {
+		return "", false, err
+	}
+	log.Printf("Removing duplicated movie %s (%s)", movie.Name, duplicate.Label)
+	pkg.AppendJobLog(fmt.Sprintf("Removing duplicated movie %s (%s)", movie.Name, duplicate.Label))
+	err = r.removeMediaFile(duplicate.MediaFileID)
+	if err != nil {
+		return "", false, err
+	}
+	log.Printf("Removing duplicated folder %s", duplicate.StorageFolder)
+	pkg.AppendJobLog(fmt.Sprintf("Removing duplicated folder %s", duplicate.StorageFolder))
+	return duplicate.StorageFolder, duplicate.MediaFileID == *movie.MediaFileID, os.RemoveAll(path.Join(destination, duplicate.StorageFolder))
 }
 
-func (r *MediaRepository) handleDuplicatedEpisode(tmdbID int, destination string) error {
-	var tvEpisode repository.Episode
-	db := r.db.Joins("MediaFile").Where("episodes.id = ?", tmdbID).First(&tvEpisode)
-	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
-		return db.Error
+// FindMovieVersions returns a movie of the library with its versions, nil if the movie has no media file.
+// It only reads the index, the main version of a movie indexed before versions were being built from its media file.
+func (r *MediaRepository) FindMovieVersions(movieID int) (*repository.Movie, []MovieVersion, error) {
+	movie, err := r.findMovie(movieID)
+	if err != nil || movie == nil || movie.MediaFileID == nil {
+		return nil, nil, err
 	}
-	if tvEpisode.ID == 0 {
-		return nil
+	versions, _, err := r.findMovieVersions(*movie)
+	if err != nil {
+		return nil, nil, err
+	}
+	return movie, versions, nil
+}
+
+// findMovieVersions returns the versions of an indexed movie and whether its main version is recorded.
+// The main version of a movie indexed before versions were is built from its media file and appended to the recorded ones.
+func (r *MediaRepository) findMovieVersions(movie repository.Movie) ([]MovieVersion, bool, error) {
+	var versions []MovieVersion
+	db := r.db.Where("movie_id = ?", movie.ID).Find(&versions)
+	if db.Error != nil {
+		return nil, false, db.Error
+	}
+	for _, version := range versions {
+		if version.MediaFileID == *movie.MediaFileID {
+			return versions, true, nil
+		}
+	}
+	info, err := r.FindMediaFileInfo(*movie.MediaFileID)
+	if err != nil {
+		return nil, false, err
+	}
+	var edition string
+	if info != nil {
+		edition = info.Edition
+	}
+	mainVersion := MovieVersion{
+		MediaFileID:   *movie.MediaFileID,
+		MovieID:       movie.ID,
+		Label:         pkg.VersionLabel(edition, mediaFileQuality(info)),
+		StorageFolder: strconv.Itoa(movie.ID),
+	}
+	return append(versions, mainVersion), false, nil
+}
+
+// saveMovieVersion links a media file to a movie as one of its versions
+func (r *MediaRepository) saveMovieVersion(movieID int, mediaFileID, label, folder string) error {
+	version := MovieVersion{
+		MediaFileID:   mediaFileID,
+		MovieID:       movieID,
+		Label:         label,
+		StorageFolder: folder,
+	}
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Create(&version).Error
+}
+
+// versionFolder returns the folder of a version of a movie other than its main one, e.g. 27205-director-s-cut-2160p
+func versionFolder(tmdbID int, label string) string {
+	return fmt.Sprintf("%d-%s", tmdbID, strings.Trim(versionFolderRegex.ReplaceAllString(strings.ToLower(label), "-"), "-"))
+}
+
+// handleDuplicatedEpisodes removes the media files already indexed for the episodes of a file, unless the duplicate policy keeps one of them.
+// Nothing is removed if any of them is kept, as a multi-episode file is indexed for all its episodes or none.
+// It returns the storage folders of the removed media files, a multi-episode file being stored in the folder of its first episode.
+// The episodes sharing a removed media file without being held by the new file are left without file until one is indexed for them.
+func (r *MediaRepository) handleDuplicatedEpisodes(tvEpisodes []pkg.TVEpisode, destination string, quality pkg.Quality) ([]string, error) {
+	var duplicates = make([]repository.Episode, 0)
+	var held = make(map[int]bool)
+	for _, episode := range tvEpisodes {
+		held[episode.ID] = true
+	}
+	var resolved = make(map[string]bool)
+	for _, episode := range tvEpisodes {
+		var tvEpisode repository.Episode
+		db := r.db.Joins("MediaFile").Where("episodes.id = ?", episode.ID).First(&tvEpisode)
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return nil, db.Error
+		}
+		if tvEpisode.ID == 0 || tvEpisode.MediaFileID == nil {
+			continue
+		}
+		// The episodes of a multi-episode file share its media file
+		if !resolved[*tvEpisode.MediaFileID] {
+			err := r.resolveDuplicate(fmt.Sprintf("tv episode %s %dx%d", tvEpisode.Name, tvEpisode.NbSeason, tvEpisode.NbEpisode), *tvEpisode.MediaFileID, quality, r.duplicatePolicy)
+			if err != nil {
+				return nil, err
+			}
+			resolved[*tvEpisode.MediaFileID] = true
+			duplicates = append(duplicates, tvEpisode)
+		}
 	}
 
-	if tvEpisode.MediaFileID != nil {
+	var folders = make([]string, 0, len(duplicates))
+	for _, tvEpisode := range duplicates {
+		sharing, err := r.findEpisodesByMediaFile(*tvEpisode.MediaFileID)
+		if err != nil {
+			return nil, err
+		}
+		var folder = strconv.Itoa(tvEpisode.ID)
+		if len(sharing) > 0 {
+			folder = strconv.Itoa(sharing[0].ID)
+		}
+		for _, other := range sharing {
+			if !held[other.ID] {
+				log.Printf("Tv episode %s %dx%d shares the duplicated file without being in the new one, it has to be indexed again", other.Name, other.NbSeason, other.NbEpisode)
+				pkg.AppendJobLog(fmt.Sprintf("Tv episode %s %dx%d shares the duplicated file without being in the new one, it has to be indexed again", other.Name, other.NbSeason, other.NbEpisode))
+			}
+		}
 		log.Printf("Removing duplicated tv episode %s %dx%d", tvEpisode.Name, tvEpisode.NbSeason, tvEpisode.NbEpisode)
 		pkg.AppendJobLog(fmt.Sprintf("Removing duplicated tv episode %s %dx%d", tvEpisode.Name, tvEpisode.NbSeason, tvEpisode.NbEpisode))
-		err := r.removeMediaFile(*tvEpisode.MediaFileID)
+		// The episodes sharing the media file are unlinked from it by the database
+		err = r.removeMediaFile(*tvEpisode.MediaFileID)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Removing duplicated file %s from folder %s", tvEpisode.MediaFile.Filename, folder)
+		pkg.AppendJobLog(fmt.Sprintf("Removing duplicated file %s from folder %s", tvEpisode.MediaFile.Filename, folder))
+		err = os.RemoveAll(path.Join(destination, folder))
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, folder)
+	}
+	return folders, nil
+}
+
+// findEpisodesByMediaFile returns the episodes a media file is indexed for, in the order of the show
+func (r *MediaRepository) findEpisodesByMediaFile(mediaFileID string) ([]repository.Episode, error) {
+	var episodes []repository.Episode
+	db := r.db.Where("media_file_id = ?", mediaFileID).Order("nb_season, nb_episode").Find(&episodes)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	return episodes, nil
+}
+
+// resolveDuplicate applies a duplicate policy to a media file already indexed, returning nil if the new file of the given quality replaces it,
+// ErrDuplicateKept if it is kept instead and ErrDuplicateVersion if both should be kept
+func (r *MediaRepository) resolveDuplicate(name, mediaFileID string, quality pkg.Quality, policy pkg.DuplicatePolicy) error {
+	switch policy {
+	case pkg.DuplicatePolicyExisting:
+		log.Printf("Keeping the indexed file of %s, as set by the duplicate policy", name)
+		pkg.AppendJobLog(fmt.Sprintf("Keeping the indexed file of %s, as set by the duplicate policy", name))
+		return ErrDuplicateKept
+	case pkg.DuplicatePolicyBoth:
+		log.Printf("Keeping both files of %s is not supported, leaving the new file in the source folder", name)
+		pkg.AppendJobLog(fmt.Sprintf("Keeping both files of %s is not supported, leaving the new file in the source folder", name))
+		return ErrDuplicateVersion
+	case pkg.DuplicatePolicyBest:
+		info, err := r.FindMediaFileInfo(mediaFileID)
 		if err != nil {
 			return err
 		}
-		log.Printf("Removing duplicated file %s", tvEpisode.MediaFile.Filename)
-		pkg.AppendJobLog(fmt.Sprintf("Removing duplicated file %s", tvEpisode.MediaFile.Filename))
-		return os.RemoveAll(path.Join(destination, strconv.Itoa(tmdbID)))
+		existing := mediaFileQuality(info)
+		if pkg.CompareQuality(quality, existing) <= 0 {
+			log.Printf("Keeping the indexed file of %s (%s), the new file (%s) is not better", name, existing, quality)
+			pkg.AppendJobLog(fmt.Sprintf("Keeping the indexed file of %s (%s), the new file (%s) is not better", name, existing, quality))
+			return ErrDuplicateKept
+		}
+		log.Printf("Replacing the indexed file of %s (%s) by a better file (%s)", name, existing, quality)
+		pkg.AppendJobLog(fmt.Sprintf("Replacing the indexed file of %s (%s) by a better file (%s)", name, existing, quality))
 	}
 	return nil
 }
 
+// FindMediaFileInfo returns the source file details of a media file, nil if it was indexed without them
+func (r *MediaRepository) FindMediaFileInfo(mediaFileID string) (*MediaFileInfo, error) {
+	var info MediaFileInfo
+	db := r.db.Where("media_file_id = ?", mediaFileID).First(&info)
+	if db.Error != nil {
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, db.Error
+	}
+	return &info, nil
+}
+
+// mediaFileQuality returns the quality of the source file of a media file from its details, unknown without them
+func mediaFileQuality(info *MediaFileInfo) pkg.Quality {
+	if info == nil {
+		return pkg.Quality{}
+	}
+	return pkg.Quality{
+		Width:      info.Width,
+		Height:     info.Height,
+		Bitrate:    info.Bitrate,
+		Codec:      info.ProbedCodec,
+		Source:     info.Source,
+		Resolution: info.Resolution,
+	}
+}
+
 func (r *MediaRepository) removeMediaFile(fileID string) error {
 	return r.db.Delete(&repository.MediaFile{}, "id = ?", fileID).Error
 }
@@ -287,6 +590,161 @@ func (r *MediaRepository) handleTvShow(name string, tmdbID int, releaseDate time
 	return entity, nil
 }
 
+// saveMediaFileInfo stores the release attributes, the quality and the content hash of the source file of a media file
+func (r *MediaRepository) saveMediaFileInfo(mediaFileID, sourceFilename, contentHash string, release *pkg.Release, quality pkg.Quality) error {
+	info := MediaFileInfo{
+		MediaFileID:    mediaFileID,
+		SourceFilename: sourceFilename,
+		ContentHash:    contentHash,
+		Width:          quality.Width,
+		Height:         quality.Height,
+		Bitrate:        quality.Bitrate,
+		ProbedCodec:    quality.Codec,
+		Resolution:     release.Resolution,
+		Source:         release.Source,
+		VideoCodec:     release.VideoCodec,
+		AudioCodec:     release.AudioCodec,
+		HDR:            release.HDR,
+		Edition:        release.Edition,
+		ReleaseGroup:   release.ReleaseGroup,
+		Languages:      strings.Join(release.Languages, ","),
+	}
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Create(&info).Error
+}
+
+// FindMediaFileInfoByContentHash returns the source file details of the media file whose source has the given content hash, nil if there is none
+func (r *MediaRepository) FindMediaFileInfoByContentHash(contentHash string) (*MediaFileInfo, error) {
+	var info MediaFileInfo
+	db := r.db.Where("content_hash = ?", contentHash).First(&info)
+	if db.Error != nil {
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, db.Error
+	}
+	return &info, nil
+}
+
+// saveSubtitleInfos stores the flags of the sidecar subtitles of a media file, subtitles and subtitleFiles sharing the same indexes
+func (r *MediaRepository) saveSubtitleInfos(mediaFileID string, subtitles []repository.Subtitle, subtitleFiles []pkg.SubtitleFile) error {
+	for i, subtitleFile := range subtitleFiles {
+		var subtitle repository.Subtitle
+		db := r.db.Where("media_file_id = ? AND filename = ?", mediaFileID, subtitles[i].Filename).First(&subtitle)
+		if db.Error != nil {
+			return db.Error
+		}
+		info := SubtitleInfo{
+			SubtitleID:     subtitle.ID,
+			SourceFilename: subtitleFile.Filename,
+			Forced:         subtitleFile.Forced,
+			SDH:            subtitleFile.SDH,
+		}
+		db = r.db.Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Create(&info)
+		if db.Error != nil {
+			return db.Error
+		}
+	}
+	return nil
+}
+
+// saveMovieMetadata creates or refreshes the TMDB details of a movie and its collection
+func (r *MediaRepository) saveMovieMetadata(movie *pkg.Movie) error {
+	metadata := MovieMetadata{
+		MovieID:          movie.ID,
+		OriginalTitle:    movie.OriginalTitle,
+		Overview:         movie.Overview,
+		Runtime:          movie.Runtime,
+		OriginalLanguage: movie.OriginalLanguage,
+		PosterPath:       movie.PosterPath,
+		BackdropPath:     movie.BackdropPath,
+		ImdbID:           movie.ImdbID,
+	}
+	if movie.Collection != nil {
+		collection := MovieCollection{
+			ID:           movie.Collection.ID,
+			Name:         movie.Collection.Name,
+			PosterPath:   movie.Collection.PosterPath,
+			BackdropPath: movie.Collection.BackdropPath,
+		}
+		db := r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&collection)
+		if db.Error != nil {
+			return db.Error
+		}
+		metadata.CollectionID = &collection.ID
+	}
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Create(&metadata).Error
+}
+
+// SaveTvShow creates or refreshes a TV show with its show-level metadata and its seasons
+func (r *MediaRepository) SaveTvShow(tvShow pkg.TVShow) error {
+	log.Printf("Updating tv show %s", tvShow.Name)
+	pkg.AppendJobLog(fmt.Sprintf("Updating tv show %s", tvShow.Name))
+	releaseDate := parseShowReleaseDate(tvShow.ReleaseDate)
+	entity, err := r.handleTvShow(tvShow.Name, tvShow.ID, releaseDate, &tvShow.Categories)
+	if err != nil {
+		return err
+	}
+	db := r.db.Model(entity).Updates(repository.TvShow{Name: tvShow.Name, ReleaseDate: releaseDate})
+	if db.Error != nil {
+		return db.Error
+	}
+	err = r.db.Model(entity).Association("Categories").Replace(*r.extractCategories(&tvShow.Categories))
+	if err != nil {
+		return err
+	}
+
+	metadata := TvShowMetadata{
+		TvShowID:     tvShow.ID,
+		OriginalName: tvShow.OriginalName,
+		Overview:     tvShow.Overview,
+		Status:       tvShow.Status,
+		Network:      tvShow.Network,
+		SeasonCount:  tvShow.SeasonCount,
+		EpisodeCount: tvShow.EpisodeCount,
+		PosterPath:   tvShow.PosterPath,
+		BackdropPath: tvShow.BackdropPath,
+	}
+	db = r.db.Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Create(&metadata)
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if len(tvShow.Seasons) == 0 {
+		return nil
+	}
+	var seasons = make([]TvSeason, len(tvShow.Seasons))
+	for i, season := range tvShow.Seasons {
+		seasons[i] = TvSeason{
+			ID:           season.ID,
+			TvShowID:     tvShow.ID,
+			NbSeason:     season.Number,
+			Name:         season.Name,
+			AirDate:      parseOptionalDate(season.AirDate),
+			EpisodeCount: season.EpisodeCount,
+			PosterPath:   season.PosterPath,
+		}
+	}
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Create(&seasons).Error
+}
+
+// FindIndexedEpisodes returns the episode numbers of a TV show having a media file in the library, indexed by season number
+func (r *MediaRepository) FindIndexedEpisodes(tvShowID int) (map[int]map[int]bool, error) {
+	var episodes []repository.Episode
+	db := r.db.Where("tv_show_id = ? AND media_file_id IS NOT NULL", tvShowID).Find(&episodes)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	var indexed = make(map[int]map[int]bool)
+	for _, episode := range episodes {
+		if indexed[episode.NbSeason] == nil {
+			indexed[episode.NbSeason] = make(map[int]bool)
+		}
+		indexed[episode.NbSeason][episode.NbEpisode] = true
+	}
+	return indexed, nil
+}
+
 func (r *MediaRepository) findMovie(tmdbID int) (*repository.Movie, error) {
 	var movie repository.Movie
 	db := r.db.Where("id = ?", tmdbID).First(&movie)
@@ -311,6 +769,65 @@ func (r *MediaRepository) findEpisode(tmdbID int) (*repository.Episode, error) {
 	return &episode, nil
 }
 
+// FindSourceFile returns the index entry of a source file, nil if it was never scanned
+func (r *MediaRepository) FindSourceFile(path string) (*SourceFile, error) {
+	var sourceFile SourceFile
+	db := r.db.Where("path = ?", path).First(&sourceFile)
+	if db.Error != nil {
+		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, db.Error
+	}
+	return &sourceFile, nil
+}
+
+// SaveSourceFile creates or updates the index entry of a source file
+func (r *MediaRepository) SaveSourceFile(sourceFile *SourceFile) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(sourceFile).Error
+}
+
+// FindSourceFilePaths returns the paths of the source files indexed inside a folder, at any depth
+func (r *MediaRepository) FindSourceFilePaths(folder string) ([]string, error) {
+	var paths = make([]string, 0)
+	var db = r.db.Model(&SourceFile{})
+	if folder = filepath.Clean(folder); folder != "." {
+		db = db.Where("path LIKE ? ESCAPE '\\'", escapeLike(folder)+"/%")
+	}
+	err := db.Pluck("path", &paths).Error
+	return paths, err
+}
+
+// DeleteSourceFiles removes the index entries of source files
+func (r *MediaRepository) DeleteSourceFiles(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	return r.db.Delete(&SourceFile{}, "path IN ?", paths).Error
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+// parseOptionalDate parses a TMDB date, returning nil if it is empty or invalid
+func parseOptionalDate(date string) *time.Time {
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+// parseShowReleaseDate parses the first air date of a TV show, returning the zero time for the shows TMDB lists without one
+func parseShowReleaseDate(date string) time.Time {
+	if parsed := parseOptionalDate(date); parsed != nil {
+		return *parsed
+	}
+	return time.Time{}
+}
+
 func getFolderSize(folderPath string) int64 {
 	var size int64
 	err := filepath.WalkDir(folderPath, func(path string, d fs.DirEntry, err error) error {
@@ -26,6 +26,6 @@ func main() {
 		cmd.Serve(env)
 	} else {
 		log.Println("Starting cli mode...")
-		cmd.ExecuteCli()
+		cmd.ExecuteCli(flag.Args())
 	}
 }
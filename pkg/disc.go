@@ -0,0 +1,315 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Types of the disc structures ripped from Blu-rays and DVDs
+const (
+	DiscBluRay = "BDMV"
+	DiscDVD    = "VIDEO_TS"
+)
+
+const concatPrefix = "concat:" // ffmpeg protocol reading several files one after another
+
+var vobRegex = regexp.MustCompile(`(?i)^VTS_(\d{2})_([1-9])\.VOB$`) // regex to match the title set and part of the VOB files of a DVD, VTS_xx_0.VOB being the menu
+
+// Disc is the main feature of a ripped Blu-ray or DVD, the longest playlist or title of the disc
+type Disc struct {
+	Type     string        // DiscBluRay or DiscDVD
+	Files    []string      // Stream files of the main feature, in playback order
+	Duration time.Duration // Duration of the main feature, 0 if unknown
+}
+
+// Input returns the input of ffmpeg and ffprobe for the main feature, its files being joined with the concat protocol
+func (d *Disc) Input() string {
+	if len(d.Files) == 1 {
+		return d.Files[0]
+	}
+	return concatPrefix + strings.Join(d.Files, "|")
+}
+
+// Stat returns the total size of the stream files of the main feature and the latest of their modification times,
+// which tell whether the disc changed since the disc folder itself is left untouched when its files are replaced
+func (d *Disc) Stat() (int64, time.Time, error) {
+	var size int64
+	var modTime time.Time
+	for _, file := range d.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		size += info.Size()
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return size, modTime, nil
+}
+
+// inputFile returns the first file of an input of ffmpeg, e.g. the first stream file of a disc
+func inputFile(input string) string {
+	if !strings.HasPrefix(input, concatPrefix) {
+		return input
+	}
+	return strings.SplitN(strings.TrimPrefix(input, concatPrefix), "|", 2)[0]
+}
+
+// findDiscFolder returns the name and the type of the BDMV or VIDEO_TS folder among the entries of a folder, empty if it doesn't hold a disc
+func findDiscFolder(entries []os.DirEntry) (string, string) {
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if strings.EqualFold(entry.Name(), DiscBluRay) {
+			return entry.Name(), DiscBluRay
+		}
+		if strings.EqualFold(entry.Name(), DiscDVD) {
+			return entry.Name(), DiscDVD
+		}
+	}
+	return "", ""
+}
+
+// ReadDisc finds the main feature of a disc folder, the folder holding its BDMV or VIDEO_TS folder
+func ReadDisc(folder string) (*Disc, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	name, discType := findDiscFolder(entries)
+	switch discType {
+	case DiscBluRay:
+		return readBluRay(filepath.Join(folder, name))
+	case DiscDVD:
+		return readDVD(filepath.Join(folder, name))
+	}
+	return nil, fmt.Errorf("no disc structure found in %s", folder)
+}
+
+// readBluRay selects the longest playlist of a BDMV folder, falling back to its longest stream if the playlists can't be read
+func readBluRay(folder string) (*Disc, error) {
+	var disc = &Disc{Type: DiscBluRay}
+	playlists, _ := findFiles(findSubfolder(folder, "PLAYLIST"), ".mpls")
+	for _, playlist := range playlists {
+		clips, duration, err := parseMPLS(playlist)
+		if err != nil {
+			log.Printf("Failed to read playlist %s: %v", playlist, err)
+			continue
+		}
+		if duration <= disc.Duration {
+			continue
+		}
+		files, err := findClipStreams(folder, clips)
+		if err != nil {
+			log.Printf("Skipping playlist %s: %v", playlist, err)
+			continue
+		}
+		disc.Files, disc.Duration = files, duration
+	}
+	if len(disc.Files) > 0 {
+		return disc, nil
+	}
+
+	streams, err := findFiles(findSubfolder(folder, "STREAM"), ".m2ts")
+	if err != nil {
+		return nil, err
+	}
+	for _, stream := range streams {
+		duration, err := RetrieveMediaDuration(stream)
+		if err != nil {
+			log.Printf("Failed to retrieve the duration of %s: %v", stream, err)
+			continue
+		}
+		if duration > disc.Duration {
+			disc.Files, disc.Duration = []string{stream}, duration
+		}
+	}
+	if len(disc.Files) == 0 {
+		return nil, fmt.Errorf("no stream found in %s", folder)
+	}
+	return disc, nil
+}
+
+// parseMPLS reads the clips of a Blu-ray playlist and its duration, summing the in and out times of its play items
+func parseMPLS(filePath string) ([]string, time.Duration, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(data) < 12 || string(data[:4]) != "MPLS" {
+		return nil, 0, errors.New("not a playlist file")
+	}
+	var offset = int(binary.BigEndian.Uint32(data[8:12]))
+	if offset+10 > len(data) {
+		return nil, 0, errors.New("truncated playlist")
+	}
+	var count = int(binary.BigEndian.Uint16(data[offset+6 : offset+8]))
+	var clips = make([]string, 0, count)
+	var ticks uint32
+	offset += 10
+	for i := 0; i < count; i++ {
+		if offset+2 > len(data) {
+			return nil, 0, errors.New("truncated playlist")
+		}
+		var length = int(binary.BigEndian.Uint16(data[offset : offset+2]))
+		var item = data[offset+2:]
+		if length < 20 || len(item) < length {
+			return nil, 0, errors.New("truncated play item")
+		}
+		// Clip name, codec identifier, flags and STC id come before the in and out times, counted at 45 kHz
+		inTime := binary.BigEndian.Uint32(item[12:16])
+		outTime := binary.BigEndian.Uint32(item[16:20])
+		if outTime > inTime {
+			ticks += outTime - inTime
+		}
+		clips = append(clips, string(item[:5]))
+		offset += 2 + length
+	}
+	return clips, time.Duration(ticks) * time.Second / 45000, nil
+}
+
+// findClipStreams returns the stream files of the clips of a playlist, a clip played several times in a row being read once
+func findClipStreams(folder string, clips []string) ([]string, error) {
+	var streams = findSubfolder(folder, "STREAM")
+	var files = make([]string, 0, len(clips))
+	for i, clip := range clips {
+		if i > 0 && clip == clips[i-1] {
+			continue
+		}
+		file, found := findFileFold(streams, clip+".m2ts")
+		if !found {
+			return nil, fmt.Errorf("stream of clip %s not found", clip)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// readDVD selects the title set of a VIDEO_TS folder with the longest program chain, falling back to the longest title set by probing its parts
+func readDVD(folder string) (*Disc, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	var titleSets = make(map[string][]string)
+	for _, entry := range entries {
+		if matches := vobRegex.FindStringSubmatch(entry.Name()); matches != nil && !entry.IsDir() {
+			titleSets[matches[1]] = append(titleSets[matches[1]], filepath.Join(folder, entry.Name()))
+		}
+	}
+	var disc = &Disc{Type: DiscDVD}
+	for titleSet, parts := range titleSets {
+		sort.Strings(parts)
+		var duration time.Duration
+		if ifo, found := findFileFold(folder, fmt.Sprintf("VTS_%s_0.IFO", titleSet)); found {
+			duration, err = parseIFODuration(ifo)
+			if err != nil {
+				log.Printf("Failed to read title set %s: %v", ifo, err)
+			}
+		}
+		if duration == 0 {
+			duration, err = RetrieveMediaDuration((&Disc{Files: parts}).Input())
+			if err != nil {
+				log.Printf("Failed to retrieve the duration of title set %s: %v", titleSet, err)
+				continue
+			}
+		}
+		if duration > disc.Duration {
+			disc.Files, disc.Duration = parts, duration
+		}
+	}
+	if len(disc.Files) == 0 {
+		return nil, fmt.Errorf("no title found in %s", folder)
+	}
+	return disc, nil
+}
+
+// parseIFODuration returns the playback time of the longest program chain of a DVD title set information file
+func parseIFODuration(filePath string) (time.Duration, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 0xD0 || string(data[:12]) != "DVDVIDEO-VTS" {
+		return 0, errors.New("not a title set information file")
+	}
+	// The table of the program chains starts at the sector given at 0xCC, followed by an 8 bytes entry per chain
+	var table = int(binary.BigEndian.Uint32(data[0xCC:0xD0])) * 2048
+	if table+8 > len(data) {
+		return 0, errors.New("truncated title set information")
+	}
+	var count = int(binary.BigEndian.Uint16(data[table : table+2]))
+	var longest time.Duration
+	for i := 0; i < count; i++ {
+		var entry = table + 8 + i*8
+		if entry+8 > len(data) {
+			return 0, errors.New("truncated program chain table")
+		}
+		var chain = table + int(binary.BigEndian.Uint32(data[entry+4:entry+8]))
+		if chain+8 > len(data) {
+			return 0, errors.New("truncated program chain")
+		}
+		if duration := parseBCDTime(data[chain+4 : chain+8]); duration > longest {
+			longest = duration
+		}
+	}
+	return longest, nil
+}
+
+// parseBCDTime decodes the playback time of a DVD program chain, hours, minutes, seconds and frames being BCD encoded,
+// the two high bits of the frames giving the frame rate
+func parseBCDTime(data []byte) time.Duration {
+	bcd := func(b byte) time.Duration { return time.Duration(b>>4*10 + b&0x0F) }
+	var fps time.Duration = 25
+	if data[3]>>6 == 3 {
+		fps = 30
+	}
+	return bcd(data[0])*time.Hour + bcd(data[1])*time.Minute + bcd(data[2])*time.Second + bcd(data[3]&0x3F)*time.Second/fps
+}
+
+// findSubfolder returns the path of a subfolder matched case-insensitively, as rips don't keep the case of the disc
+func findSubfolder(folder, name string) string {
+	if subfolder, found := findFileFold(folder, name); found {
+		return subfolder
+	}
+	return filepath.Join(folder, name)
+}
+
+// findFileFold returns the path of the entry of a folder with the given name, matched case-insensitively
+func findFileFold(folder, name string) (string, bool) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), name) {
+			return filepath.Join(folder, entry.Name()), true
+		}
+	}
+	return "", false
+}
+
+// findFiles returns the files of a folder with the given extension, matched case-insensitively
+func findFiles(folder, extension string) ([]string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	var files = make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), extension) {
+			files = append(files, filepath.Join(folder, entry.Name()))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var discFixtureFolder = filepath.Join("testdata", "disc")
+
+func TestParseMPLS(t *testing.T) {
+	var tests = []struct {
+		filename string
+		clips    []string
+		duration time.Duration
+		ok       bool
+	}{
+		{filename: "main.mpls", clips: []string{"00001", "00002"}, duration: 90 * time.Second, ok: true},
+		{filename: "truncated-item.mpls"},
+		{filename: "truncated.mpls"},
+		{filename: "not-a-playlist.mpls"},
+		{filename: "missing.mpls"},
+	}
+	for _, test := range tests {
+		clips, duration, err := parseMPLS(filepath.Join(discFixtureFolder, test.filename))
+		if (err == nil) != test.ok || !reflect.DeepEqual(clips, test.clips) || duration != test.duration {
+			t.Errorf("parseMPLS(%q) = %q, %v, %v, want %q, %v, ok %t", test.filename, clips, duration, err, test.clips, test.duration, test.ok)
+		}
+	}
+}
+
+func TestParseIFODuration(t *testing.T) {
+	var tests = []struct {
+		filename string
+		duration time.Duration
+		ok       bool
+	}{
+		{filename: "VTS_01_0.IFO", duration: time.Hour + 23*time.Minute + 45*time.Second + 12*time.Second/25, ok: true},
+		{filename: "truncated.IFO"},
+		{filename: "not-an-ifo.IFO"},
+		{filename: "main.mpls"},
+		{filename: "missing.IFO"},
+	}
+	for _, test := range tests {
+		duration, err := parseIFODuration(filepath.Join(discFixtureFolder, test.filename))
+		if (err == nil) != test.ok || duration != test.duration {
+			t.Errorf("parseIFODuration(%q) = %v, %v, want %v, ok %t", test.filename, duration, err, test.duration, test.ok)
+		}
+	}
+}
+
+func TestParseBCDTime(t *testing.T) {
+	var tests = []struct {
+		data     []byte
+		expected time.Duration
+	}{
+		{data: []byte{0x01, 0x23, 0x45, 0x40 | 0x12}, expected: time.Hour + 23*time.Minute + 45*time.Second + 12*time.Second/25},
+		{data: []byte{0x00, 0x02, 0x30, 0xC0 | 0x15}, expected: 2*time.Minute + 30*time.Second + 15*time.Second/30},
+		{data: []byte{0x00, 0x00, 0x00, 0x00}},
+	}
+	for _, test := range tests {
+		if result := parseBCDTime(test.data); result != test.expected {
+			t.Errorf("parseBCDTime(%x) = %v, want %v", test.data, result, test.expected)
+		}
+	}
+}
+
+// TestDiscHashContent checks that the content hash of a disc covers all the stream files of its main feature
+func TestDiscHashContent(t *testing.T) {
+	var folder = t.TempDir()
+	var streams = []string{filepath.Join(folder, "00001.m2ts"), filepath.Join(folder, "00002.m2ts")}
+	for i, stream := range streams {
+		if err := os.WriteFile(stream, []byte{byte(i), 1, 2, 3}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var disc = MovieFile{Path: folder, Filename: "Movie", Disc: &Disc{Type: DiscBluRay, Files: streams}}
+	disc.HashContent()
+	var firstStream = MovieFile{Path: folder, Filename: "00001.m2ts"}
+	firstStream.HashContent()
+	if disc.ContentHash == "" || disc.ContentHash == firstStream.ContentHash {
+		t.Fatalf("disc hash = %q, want a hash other than the one of its first stream %q", disc.ContentHash, firstStream.ContentHash)
+	}
+
+	if err := os.WriteFile(streams[1], []byte{9, 9, 9, 9}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var changed = disc
+	changed.HashContent()
+	if changed.ContentHash == disc.ContentHash {
+		t.Errorf("disc hash = %q after its second stream changed, want another hash", changed.ContentHash)
+	}
+}
+
+// TestDiscStat checks that the size and the modification time of a disc are the ones of the stream files of its main feature
+func TestDiscStat(t *testing.T) {
+	var folder = t.TempDir()
+	var streams = []string{filepath.Join(folder, "00001.m2ts"), filepath.Join(folder, "00002.m2ts")}
+	var modTimes = []time.Time{time.Date(2023, 5, 2, 10, 0, 0, 0, time.UTC), time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)}
+	for i, stream := range streams {
+		if err := os.WriteFile(stream, make([]byte, 10*(i+1)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(stream, modTimes[i], modTimes[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var disc = &Disc{Type: DiscBluRay, Files: streams}
+	size, modTime, err := disc.Stat()
+	if err != nil || size != 30 || !modTime.Equal(modTimes[0]) {
+		t.Errorf("Stat() = %d, %v, %v, want 30, %v, nil", size, modTime, err, modTimes[0])
+	}
+
+	if err := os.Remove(streams[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = disc.Stat(); err == nil {
+		t.Error("Stat() succeeded with a missing stream file, want an error")
+	}
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const contentHashChunkSize = 1 << 20 // size of the head and tail chunks of a file read to hash its content
+
+// ContentHash returns a fast partial hash of the content of a file, computed from its size and its first and last megabytes.
+// Two copies of a release hash the same whatever their names, while reading only a small part of them.
+func ContentHash(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if err = binary.Write(hash, binary.BigEndian, info.Size()); err != nil {
+		return "", err
+	}
+	if _, err = io.CopyN(hash, file, contentHashChunkSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	if info.Size() > contentHashChunkSize {
+		tail := info.Size() - contentHashChunkSize
+		if tail < contentHashChunkSize {
+			tail = contentHashChunkSize
+		}
+		if _, err = io.Copy(hash, io.NewSectionReader(file, tail, info.Size()-tail)); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// HashContent sets the content hash of a movie file, the one of all the stream files of the main feature of a disc.
+// It is called once the unchanged files are skipped, hashing reading a part of every file.
+func (m *MovieFile) HashContent() {
+	if m.Disc != nil {
+		m.ContentHash = findContentHash(m.Disc.Files...)
+		return
+	}
+	m.ContentHash = findContentHash(filepath.Join(m.Path, m.Filename))
+}
+
+// HashContent sets the content hash of a TV show file.
+// It is called once the unchanged files are skipped, hashing reading a part of every file.
+func (t *TVShowFile) HashContent() {
+	t.ContentHash = findContentHash(filepath.Join(t.Path, t.Filename))
+}
+
+// findContentHash returns the content hash of a file, or of several files read one after another by hashing their content hashes,
+// logging instead of failing since the hash is only used to detect duplicates
+func findContentHash(filePaths ...string) string {
+	if len(filePaths) == 0 {
+		return ""
+	}
+	var hashes = make([]string, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		hash, err := ContentHash(filePath)
+		if err != nil {
+			log.Printf("Failed to hash %s: %v", filePath, err)
+			return ""
+		}
+		hashes = append(hashes, hash)
+	}
+	if len(hashes) == 1 {
+		return hashes[0]
+	}
+	hash := sha256.Sum256([]byte(strings.Join(hashes, "\n")))
+	return hex.EncodeToString(hash[:])
+}
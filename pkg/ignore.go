@@ -0,0 +1,236 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	ignoreFilename   = ".indexerignore"
+	sampleMaxBitrate = 100_000_000 // Highest overall bitrate of a sample in bits per second, the one of an UHD remux
+)
+
+var extrasFolderRegex = regexp.MustCompile(`(?i)^(samples?|extras?|featurettes?|trailers?|behind[ ._-]?the[ ._-]?scenes|deleted[ ._-]?scenes|interviews?|bonus(es)?)$`) // regex to match sample and extras folders
+var sampleFileRegex = regexp.MustCompile(`(?i)^sample$|^sample-|-sample$`)                                                                                              // regex to match sample file names, e.g. sample.mkv, movie-sample.mkv, sample-movie.mkv
+var sampleTagRegex = regexp.MustCompile(`(?i)^sample[._]|[._]sample$`)                                                                                                  // regex to match a sample tag leading or ending a release name, e.g. Movie.2019.1080p.sample.mkv
+var extrasFileRegex = regexp.MustCompile(`(?i)-(trailer|featurette|behindthescenes|deleted|interview)\.[^.]+$`)                                                         // regex to match extras named after their movie, e.g. Inception-trailer.mkv
+
+// ignoreRule is a gitignore-style pattern of an .indexerignore file or of the global ignore patterns
+type ignoreRule struct {
+	base     string   // Folder of the .indexerignore file, relative to the scanned source
+	segments []string // Path segments of the pattern, "**" matching any number of folders
+	negate   bool     // Pattern starting with "!", re-including the paths matched by previous rules
+	dirOnly  bool     // Pattern ending with "/", only matching folders
+}
+
+type ignoreRules []ignoreRule
+
+// parseIgnorePatterns parses gitignore-style patterns, blank lines and lines starting with "#" being skipped.
+// The base is the folder the patterns are relative to, from the scanned source.
+func parseIgnorePatterns(patterns []string, base string) ignoreRules {
+	var rules = make(ignoreRules, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		var rule = ignoreRule{base: base}
+		if strings.HasPrefix(pattern, "!") {
+			rule.negate = true
+			pattern = pattern[1:]
+		}
+		if strings.HasSuffix(pattern, "/") {
+			rule.dirOnly = true
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		// A pattern without slash matches at any depth, otherwise it is anchored to its base folder
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		rule.segments = strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// readIgnoreFile parses the .indexerignore file of a folder, if any, and returns the rules completed by its patterns
+func (r ignoreRules) readIgnoreFile(folder string, entries []os.DirEntry, folders []string) ignoreRules {
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() != ignoreFilename {
+			continue
+		}
+		patterns, err := readLines(filepath.Join(folder, ignoreFilename))
+		if err != nil {
+			log.Printf("Failed to read %s: %v", filepath.Join(folder, ignoreFilename), err)
+			return r
+		}
+		// Copy the rules so that sibling folders don't share them
+		var rules = make(ignoreRules, 0, len(r)+len(patterns))
+		rules = append(rules, r...)
+		return append(rules, parseIgnorePatterns(patterns, path.Join(folders...))...)
+	}
+	return r
+}
+
+// ignored tells whether a path relative to the scanned source is ignored, the last matching rule winning
+func (r ignoreRules) ignored(relativePath string, isDir bool) bool {
+	var ignored = false
+	for _, rule := range r {
+		if rule.matches(relativePath, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) matches(relativePath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(relativePath, r.base+"/") {
+			return false
+		}
+		relativePath = strings.TrimPrefix(relativePath, r.base+"/")
+	}
+	return matchSegments(r.segments, strings.Split(relativePath, "/"))
+}
+
+// matchSegments matches path segments against pattern segments, "**" matching any number of segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// isIgnored tells whether an entry of a folder is skipped, because of the ignore rules or as a sample or extras, logging the reason
+func isIgnored(entry os.DirEntry, folders []string, rules ignoreRules) bool {
+	if entry.Name() == ignoreFilename {
+		return true
+	}
+	relativePath := path.Join(appendFolder(folders, entry.Name())...)
+	if rules.ignored(relativePath, entry.IsDir()) {
+		log.Println("Ignoring, matched by an ignore rule: ", relativePath)
+		return true
+	}
+	if entry.IsDir() && isExtrasFolder(entry.Name(), folders) {
+		log.Println("Ignoring samples or extras folder: ", relativePath)
+		return true
+	}
+	if !entry.IsDir() && isSampleFilename(entry.Name()) {
+		log.Println("Ignoring sample or extra: ", relativePath)
+		return true
+	}
+	return false
+}
+
+// isExtrasFolder tells whether a folder holds samples or extras (trailers, featurettes...) rather than the media itself.
+// Only the folders of a movie or a season hold extras, so that a show or a movie titled e.g. "Extras" is still scanned.
+func isExtrasFolder(name string, folders []string) bool {
+	return len(folders) > 0 && isMediaFolder(folders[len(folders)-1]) && extrasFolderRegex.MatchString(name)
+}
+
+// isMediaFolder tells whether a folder is recognised as the folder of a movie or of a season,
+// by its release year or season number (e.g. "Inception (2010)", "Show.S01.1080p") or as a season or specials folder
+func isMediaFolder(name string) bool {
+	if seasonFolderRegex.MatchString(name) || specialsFolderRegex.MatchString(name) {
+		return true
+	}
+	release := ParseReleaseFolder(name)
+	return release.Year != "" || release.Season > 0
+}
+
+// isSampleFilename tells whether a file is a sample or an extra by its name.
+// A "sample" word leading or ending the name is only a sample tag when it is not part of the parsed title,
+// so that e.g. "The.Sample.2019.1080p.mkv" is scanned, the other samples being caught by their size or duration.
+func isSampleFilename(filename string) bool {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if sampleFileRegex.MatchString(name) || extrasFileRegex.MatchString(filename) {
+		return true
+	}
+	if !sampleTagRegex.MatchString(name) {
+		return false
+	}
+	title := strings.Fields(strings.ToLower(ParseRelease(filename).Title))
+	if len(title) == 0 {
+		return true
+	}
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "sample") && title[0] != "sample" {
+		return true
+	}
+	return strings.HasSuffix(lower, "sample") && title[len(title)-1] != "sample"
+}
+
+// isSampleSize tells whether a media file is a sample by its size, returning the reason if so.
+// It only stats the file, the duration being probed by IsShortSample once the unchanged files are skipped.
+func (o TreeOptions) isSampleSize(filePath string) (string, bool) {
+	if o.SampleMaxSize > 0 {
+		info, err := os.Stat(filePath)
+		if err == nil && info.Size() <= o.SampleMaxSize {
+			return fmt.Sprintf("size %d bytes", info.Size()), true
+		}
+	}
+	return "", false
+}
+
+// IsShortSample tells whether a media file is a sample by its duration, returning the reason if so.
+// The duration is only probed when the size of the file is inconclusive, a file bigger than the maximal duration
+// of a sample at sampleMaxBitrate being no sample.
+func (o TreeOptions) IsShortSample(filePath string) (string, bool) {
+	if o.SampleMaxDuration <= 0 {
+		return "", false
+	}
+	info, err := os.Stat(filePath)
+	if err != nil || info.IsDir() || info.Size() > int64(o.SampleMaxDuration.Seconds()*sampleMaxBitrate/8) {
+		return "", false
+	}
+	duration, err := RetrieveMediaDuration(filePath)
+	if err != nil {
+		log.Printf("Failed to retrieve the duration of %s: %v", filePath, err)
+		return "", false
+	}
+	if duration <= o.SampleMaxDuration {
+		return fmt.Sprintf("duration %s", duration.Round(time.Second)), true
+	}
+	return "", false
+}
+
+// readLines reads the non-blank lines of a text file
+func readLines(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var lines = make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsExtrasFolder(t *testing.T) {
+	var tests = []struct {
+		name     string
+		folders  []string
+		expected bool
+	}{
+		{name: "Extras", folders: []string{"Inception (2010)"}, expected: true},
+		{name: "Featurettes", folders: []string{"Movies", "Inception.2010.1080p.BluRay"}, expected: true},
+		{name: "Behind The Scenes", folders: []string{"Inception (2010)"}, expected: true},
+		{name: "Sample", folders: []string{"Show", "Season 1"}, expected: true},
+		{name: "Interviews", folders: []string{"Show", "Specials"}, expected: true},
+		{name: "Trailers", folders: []string{"Show.S02.1080p.WEB-DL"}, expected: true},
+		{name: "Extras", folders: []string{}},
+		{name: "Extras", folders: []string{"TV Shows"}},
+		{name: "Interview", folders: []string{"Movies"}},
+		{name: "Season 1", folders: []string{"Extras"}},
+		{name: "Extras 2", folders: []string{"Inception (2010)"}},
+		{name: "Subs", folders: []string{"Inception (2010)"}},
+	}
+	for _, test := range tests {
+		if result := isExtrasFolder(test.name, test.folders); result != test.expected {
+			t.Errorf("isExtrasFolder(%q, %q) = %t, want %t", test.name, test.folders, result, test.expected)
+		}
+	}
+}
+
+func TestIsSampleFilename(t *testing.T) {
+	var tests = []struct {
+		filename string
+		expected bool
+	}{
+		{filename: "sample.mkv", expected: true},
+		{filename: "Sample.mkv", expected: true},
+		{filename: "Inception-sample.mkv", expected: true},
+		{filename: "sample-inception.mkv", expected: true},
+		{filename: "Inception.2010.1080p.BluRay.x264.sample.mkv", expected: true},
+		{filename: "Show.S01E01.720p.HDTV_sample.mkv", expected: true},
+		{filename: "Inception-trailer.mkv", expected: true},
+		{filename: "Inception-featurette.mp4", expected: true},
+		{filename: "The.Sample.2019.1080p.mkv"},
+		{filename: "Sample.Movie.2019.mkv"},
+		{filename: "The Sample (2019).mkv"},
+		{filename: "The.Sample.mkv"},
+		{filename: "Inception.2010.1080p.mkv"},
+		{filename: "Trailer.Park.Boys.S01E01.mkv"},
+	}
+	for _, test := range tests {
+		if result := isSampleFilename(test.filename); result != test.expected {
+			t.Errorf("isSampleFilename(%q) = %t, want %t", test.filename, result, test.expected)
+		}
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	var tests = []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "**/*.nfo", name: "movie.nfo", expected: true},
+		{pattern: "**/*.nfo", name: "Movies/Inception/movie.nfo", expected: true},
+		{pattern: "**/*.nfo", name: "Movies/Inception/movie.mkv"},
+		{pattern: "Movies/*", name: "Movies/Inception", expected: true},
+		{pattern: "Movies/*", name: "Movies/Inception/movie.mkv"},
+		{pattern: "Movies/**", name: "Movies/Inception/movie.mkv", expected: true},
+		{pattern: "Movies/**/Extras", name: "Movies/Extras", expected: true},
+		{pattern: "Movies/**/Extras", name: "Movies/Inception/Extras", expected: true},
+		{pattern: "Movies/**/Extras", name: "Shows/Inception/Extras"},
+		{pattern: "Show ?", name: "Show 1", expected: true},
+		{pattern: "Show ?", name: "Show 10"},
+		{pattern: "Movies", name: ""},
+	}
+	for _, test := range tests {
+		if result := matchSegments(strings.Split(test.pattern, "/"), strings.Split(test.name, "/")); result != test.expected {
+			t.Errorf("matchSegments(%q, %q) = %t, want %t", test.pattern, test.name, result, test.expected)
+		}
+	}
+}
+
+func TestParseIgnorePatterns(t *testing.T) {
+	var patterns = []string{
+		"# comment",
+		"",
+		"  *.nfo  ",
+		"!keep.nfo",
+		"Extras/",
+		"/Movies/Old",
+		"Shows/**/Sample",
+	}
+	var expected = ignoreRules{
+		{base: "Library", segments: []string{"**", "*.nfo"}},
+		{base: "Library", segments: []string{"**", "keep.nfo"}, negate: true},
+		{base: "Library", segments: []string{"**", "Extras"}, dirOnly: true},
+		{base: "Library", segments: []string{"Movies", "Old"}},
+		{base: "Library", segments: []string{"Shows", "**", "Sample"}},
+	}
+	rules := parseIgnorePatterns(patterns, "Library")
+	if !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("parseIgnorePatterns(%q) = %+v, want %+v", patterns, rules, expected)
+	}
+	var tests = []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{path: "Library/movie.nfo", expected: true},
+		{path: "Library/Movies/Inception/movie.nfo", expected: true},
+		{path: "Library/Movies/Inception/keep.nfo"},
+		{path: "Other/movie.nfo"},
+		{path: "Library/Movies/Inception/Extras", isDir: true, expected: true},
+		{path: "Library/Movies/Inception/Extras"},
+		{path: "Library/Movies/Old", isDir: true, expected: true},
+		{path: "Library/Archive/Movies/Old", isDir: true},
+		{path: "Library/Shows/Show/Season 1/Sample", isDir: true, expected: true},
+		{path: "Library/Movies/Inception/Sample", isDir: true},
+	}
+	for _, test := range tests {
+		if result := rules.ignored(test.path, test.isDir); result != test.expected {
+			t.Errorf("ignored(%q, %t) = %t, want %t", test.path, test.isDir, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var mediaIDRegex = regexp.MustCompile(`(?i)[\[{]\s*(tmdb|imdb|tvdb)(?:id)?\s*[-=:]\s*(tt\d+|\d+)\s*[\]}]`) // regex to extract ID tags, e.g. {tmdb-27205}, [imdbid-tt1375666], [tvdbid=81189]
+
+// MediaIDs holds the provider IDs tagged in the name of a file or of its folders, e.g. "Inception (2010) {tmdb-27205}".
+// When one is present, the media is looked up directly by ID instead of searching its title.
+type MediaIDs struct {
+	TMDB int    // TMDB ID of the movie or TV show
+	IMDB string // IMDb ID of the movie or TV show (e.g. tt1375666)
+	TVDB int    // TheTVDB ID of the TV show
+}
+
+// IsEmpty reports whether no ID was found
+func (m MediaIDs) IsEmpty() bool {
+	return m.TMDB == 0 && m.IMDB == "" && m.TVDB == 0
+}
+
+// ParseMediaIDs extracts the tmdb, imdb and tvdb ID tags of a file or folder name
+func ParseMediaIDs(name string) MediaIDs {
+	var ids MediaIDs
+	for _, matches := range mediaIDRegex.FindAllStringSubmatch(name, -1) {
+		value := strings.ToLower(matches[2])
+		switch strings.ToLower(matches[1]) {
+		case "tmdb":
+			ids.TMDB, _ = strconv.Atoi(value)
+		case "imdb":
+			if strings.HasPrefix(value, "tt") {
+				ids.IMDB = value
+			}
+		case "tvdb":
+			ids.TVDB, _ = strconv.Atoi(value)
+		}
+	}
+	return ids
+}
+
+// FindMediaIDs extracts the ID tags of a file, completed by the ones of its parent folders from the nearest to the farthest
+func FindMediaIDs(filename string, folders []string) MediaIDs {
+	ids := ParseMediaIDs(filename)
+	for i := len(folders) - 1; i >= 0; i-- {
+		ids = ids.Complete(ParseMediaIDs(folders[i]))
+	}
+	return ids
+}
+
+// Complete returns the IDs completed by the ones of other that are missing
+func (m MediaIDs) Complete(other MediaIDs) MediaIDs {
+	if m.TMDB == 0 {
+		m.TMDB = other.TMDB
+	}
+	if m.IMDB == "" {
+		m.IMDB = other.IMDB
+	}
+	if m.TVDB == 0 {
+		m.TVDB = other.TVDB
+	}
+	return m
+}
+
+// stripMediaIDs removes the ID tags of a name so that they don't end up in the title
+func stripMediaIDs(name string) string {
+	return mediaIDRegex.ReplaceAllString(name, " ")
+}
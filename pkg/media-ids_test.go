@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func TestParseMediaIDs(t *testing.T) {
+	var tests = []struct {
+		name     string
+		expected MediaIDs
+	}{
+		{name: "Inception (2010) {tmdb-27205}", expected: MediaIDs{TMDB: 27205}},
+		{name: "Inception (2010) [imdbid-tt1375666]", expected: MediaIDs{IMDB: "tt1375666"}},
+		{name: "Breaking Bad [tvdbid=81189]", expected: MediaIDs{TVDB: 81189}},
+		{name: "Breaking Bad {TMDB:1396} {tvdb-81189}", expected: MediaIDs{TMDB: 1396, TVDB: 81189}},
+		{name: "Inception {imdb-TT1375666}.mkv", expected: MediaIDs{IMDB: "tt1375666"}},
+		{name: "Inception { tmdb = 27205 }.mkv", expected: MediaIDs{TMDB: 27205}},
+		{name: "Inception {imdb-1375666}", expected: MediaIDs{}},
+		{name: "Inception tmdb-27205", expected: MediaIDs{}},
+		{name: "Inception (2010) [1080p]", expected: MediaIDs{}},
+	}
+	for _, test := range tests {
+		if ids := ParseMediaIDs(test.name); ids != test.expected {
+			t.Errorf("ParseMediaIDs(%q) = %+v, want %+v", test.name, ids, test.expected)
+		}
+	}
+}
+
+func TestFindMediaIDs(t *testing.T) {
+	var tests = []struct {
+		filename string
+		folders  []string
+		expected MediaIDs
+	}{
+		{filename: "movie.mkv", folders: []string{"Inception (2010) {tmdb-27205}"}, expected: MediaIDs{TMDB: 27205}},
+		// The nearest ID wins
+		{filename: "S01E01.mkv", folders: []string{"Show {tmdb-1}", "Season 1 {tmdb-2}"}, expected: MediaIDs{TMDB: 2}},
+		{filename: "S01E01 {tmdb-3}.mkv", folders: []string{"Show {tmdb-1} {tvdb-81189}"}, expected: MediaIDs{TMDB: 3, TVDB: 81189}},
+		{filename: "S01E01.mkv", folders: nil, expected: MediaIDs{}},
+	}
+	for _, test := range tests {
+		if ids := FindMediaIDs(test.filename, test.folders); ids != test.expected {
+			t.Errorf("FindMediaIDs(%q, %q) = %+v, want %+v", test.filename, test.folders, ids, test.expected)
+		}
+	}
+}
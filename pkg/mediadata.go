@@ -28,6 +28,9 @@ type MediaData struct {
 	Size      float64        // The size of the media file in bytes
 	Duration  float64        // The duration of the media file in seconds
 	Codec     string         // The codec used to encode the video (e.g. H.264, H.265, etc)
+	Width     int            // The width of the video in pixels
+	Height    int            // The height of the video in pixels
+	Bitrate   int64          // The overall bitrate of the media file in bits per second
 	Mimetype  string         // File MIME Type
 	Audios    []AudioData    // An array of AudioData structs representing the audio streams in the media file
 	Subtitles []SubtitleData // An array of SubtitleData structs representing the subtitle streams in the media file
@@ -49,7 +52,7 @@ func RetrieveMediaData(filePath string) (MediaData, error) {
 	// Initialize a new MediaData struct
 	var mediaData MediaData
 
-	err = extractMimetype(filePath, &mediaData)
+	err = extractMimetype(inputFile(filePath), &mediaData)
 	if err != nil {
 		log.Println("Error extracting mimetype:", err)
 		return MediaData{}, err
@@ -76,6 +79,18 @@ func RetrieveMediaData(filePath string) (MediaData, error) {
 	return mediaData, nil
 }
 
+// RetrieveMediaDuration retrieves the duration of a media file using ffprobe
+func RetrieveMediaDuration(filePath string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := ffprobe.ProbeURL(ctx, filePath)
+	if err != nil {
+		return 0, err
+	}
+	return data.Format.Duration(), nil
+}
+
 func extractMimetype(path string, m *MediaData) error {
 	mimetype, err := mimetype2.DetectFile(path)
 	if err != nil {
@@ -98,11 +113,19 @@ func extractVideoStream(data *ffprobe.ProbeData, mediaData *MediaData) error {
 	// Set the duration of the media file
 	mediaData.Duration = data.Format.DurationSeconds
 
+	// Parse the overall bitrate of the media file, missing from some containers
+	bitrate, err := strconv.ParseInt(data.Format.BitRate, 10, 64)
+	if err == nil {
+		mediaData.Bitrate = bitrate
+	}
+
 	// Extract the first video stream from the ffprobe data
 	videoStream := data.FirstVideoStream()
 
 	// Set the video codec for the media file
 	mediaData.Codec = strings.ToUpper(videoStream.CodecName)
+	mediaData.Width = videoStream.Width
+	mediaData.Height = videoStream.Height
 
 	// Return nil to indicate successful extraction
 	return nil
@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	movieNFOFilename  = "movie.nfo"
+	tvShowNFOFilename = "tvshow.nfo"
+)
+
+var nfoURLRegexes = map[string]*regexp.Regexp{ // regexes to extract IDs of NFO files holding a bare URL
+	"imdb": regexp.MustCompile(`imdb\.com/title/(tt\d+)`),
+	"tmdb": regexp.MustCompile(`themoviedb\.org/(?:movie|tv)/(\d+)`),
+	"tvdb": regexp.MustCompile(`thetvdb\.com/.*[?&]id=(\d+)`),
+}
+
+// NFO holds the match hints of a Kodi NFO sidecar (movie.nfo, <filename>.nfo or tvshow.nfo).
+// They are more reliable than the ones parsed from filenames, as they were written by the media manager that matched the file.
+type NFO struct {
+	Path  string   // Path of the NFO file
+	Title string   // Title of the movie or TV show
+	Year  string   // Release year of the movie or first air year of the TV show
+	IDs   MediaIDs // Provider IDs of the movie or TV show
+}
+
+// nfoDocument is the subset of the Kodi movie and tvshow NFO formats holding match hints
+type nfoDocument struct {
+	Title     string `xml:"title"`
+	Year      string `xml:"year"`
+	Premiered string `xml:"premiered"`
+	Aired     string `xml:"aired"`
+	UniqueIDs []struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"uniqueid"`
+	ID     string `xml:"id"`     // Legacy ID element, IMDb for movies and TVDB for TV shows
+	IMDBID string `xml:"imdbid"` // Legacy IMDb ID element
+	TMDBID string `xml:"tmdbid"` // Legacy TMDB ID element
+	TVDBID string `xml:"tvdbid"` // Legacy TVDB ID element
+}
+
+// ParseNFO reads the NFO file at the given path. Besides Kodi XML documents, NFO files holding only an IMDb, TMDB or TVDB URL are supported.
+func ParseNFO(path string) (*NFO, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var nfo = NFO{Path: path}
+	var document nfoDocument
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	// Kodi allows a URL after the XML document, the decoder stops at the end of the root element
+	if err = decoder.Decode(&document); err == nil {
+		nfo.Title = strings.TrimSpace(document.Title)
+		nfo.Year = parseNFOYear(document)
+		nfo.IDs = parseNFOIDs(document)
+	}
+	nfo.IDs = nfo.IDs.Complete(parseNFOURLs(string(content)))
+	if nfo.Title == "" && nfo.IDs.IsEmpty() {
+		return nil, fmt.Errorf("no title nor ID found in %s", path)
+	}
+	return &nfo, nil
+}
+
+func parseNFOYear(document nfoDocument) string {
+	for _, date := range []string{document.Year, document.Premiered, document.Aired} {
+		date = strings.TrimSpace(date)
+		if len(date) >= 4 && isPlausibleYear(date[:4]) {
+			return date[:4]
+		}
+	}
+	return ""
+}
+
+func parseNFOIDs(document nfoDocument) MediaIDs {
+	var ids MediaIDs
+	for _, uniqueID := range document.UniqueIDs {
+		ids = ids.Complete(parseNFOID(uniqueID.Type, uniqueID.Value))
+	}
+	ids = ids.Complete(parseNFOID("imdb", document.IMDBID))
+	ids = ids.Complete(parseNFOID("tmdb", document.TMDBID))
+	ids = ids.Complete(parseNFOID("tvdb", document.TVDBID))
+	if id := strings.TrimSpace(document.ID); strings.HasPrefix(id, "tt") {
+		ids = ids.Complete(parseNFOID("imdb", id))
+	} else {
+		ids = ids.Complete(parseNFOID("tvdb", id))
+	}
+	return ids
+}
+
+func parseNFOID(idType, value string) MediaIDs {
+	var ids MediaIDs
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(strings.TrimSpace(idType)) {
+	case "imdb":
+		if strings.HasPrefix(value, "tt") {
+			ids.IMDB = value
+		}
+	case "tmdb":
+		ids.TMDB, _ = strconv.Atoi(value)
+	case "tvdb":
+		ids.TVDB, _ = strconv.Atoi(value)
+	}
+	return ids
+}
+
+func parseNFOURLs(content string) MediaIDs {
+	var ids MediaIDs
+	for idType, regex := range nfoURLRegexes {
+		if matches := regex.FindStringSubmatch(content); matches != nil {
+			ids = ids.Complete(parseNFOID(idType, matches[1]))
+		}
+	}
+	return ids
+}
+
+// findMovieNFO returns the NFO sidecar of a movie file, named after the file or movie.nfo, nil if there is none or if it can't be read
+func findMovieNFO(folder string, entries []os.DirEntry, filename string) *NFO {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".nfo"
+	for _, nfoFilename := range []string{name, movieNFOFilename} {
+		if nfo := readNFO(folder, entries, nfoFilename); nfo != nil {
+			return nfo
+		}
+	}
+	return nil
+}
+
+// findTVShowNFO returns the tvshow.nfo sidecar of a folder, nil if there is none or if it can't be read
+func findTVShowNFO(folder string, entries []os.DirEntry) *NFO {
+	return readNFO(folder, entries, tvShowNFOFilename)
+}
+
+// readNFO parses the NFO file of the folder matching the given name case-insensitively, logging instead of failing
+// since a broken sidecar must not prevent the file from being matched by its name
+func readNFO(folder string, entries []os.DirEntry, nfoFilename string) *NFO {
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(entry.Name(), nfoFilename) {
+			continue
+		}
+		nfo, err := ParseNFO(filepath.Join(folder, entry.Name()))
+		if err != nil {
+			log.Printf("Ignoring NFO file %s: %v", filepath.Join(folder, entry.Name()), err)
+			return nil
+		}
+		return nfo
+	}
+	return nil
+}
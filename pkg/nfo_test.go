@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseNFO(t *testing.T) {
+	var tests = []struct {
+		name     string
+		content  string
+		expected *NFO // Path excluded, nil if the NFO can't be used
+	}{
+		{
+			name: "kodi movie",
+			content: `<?xml version="1.0" encoding="UTF-8" standalone="yes" ?>
+<movie>
+	<title>Inception</title>
+	<year>2010</year>
+	<uniqueid type="imdb" default="true">tt1375666</uniqueid>
+	<uniqueid type="tmdb">27205</uniqueid>
+</movie>`,
+			expected: &NFO{Title: "Inception", Year: "2010", IDs: MediaIDs{TMDB: 27205, IMDB: "tt1375666"}},
+		},
+		{
+			name: "kodi tv show with legacy ids",
+			content: `<tvshow>
+	<title> Breaking Bad </title>
+	<premiered>2008-01-20</premiered>
+	<id>81189</id>
+	<tmdbid>1396</tmdbid>
+</tvshow>`,
+			expected: &NFO{Title: "Breaking Bad", Year: "2008", IDs: MediaIDs{TMDB: 1396, TVDB: 81189}},
+		},
+		{
+			name:     "legacy imdb id",
+			content:  `<movie><title>Heat</title><id>tt0113277</id><aired>1995-12-15</aired></movie>`,
+			expected: &NFO{Title: "Heat", Year: "1995", IDs: MediaIDs{IMDB: "tt0113277"}},
+		},
+		{
+			name:     "latin1 declaration",
+			content:  `<?xml version="1.0" encoding="ISO-8859-1"?><movie><title>Amelie</title><year>2001</year></movie>`,
+			expected: &NFO{Title: "Amelie", Year: "2001"},
+		},
+		{
+			name:     "implausible year",
+			content:  `<movie><title>Metropolis</title><year>0</year></movie>`,
+			expected: &NFO{Title: "Metropolis"},
+		},
+		{
+			name: "url after the document",
+			content: `<movie><title>Inception</title></movie>
+https://www.themoviedb.org/movie/27205-inception`,
+			expected: &NFO{Title: "Inception", IDs: MediaIDs{TMDB: 27205}},
+		},
+		{
+			name:     "bare imdb url",
+			content:  "https://www.imdb.com/title/tt1375666/\n",
+			expected: &NFO{IDs: MediaIDs{IMDB: "tt1375666"}},
+		},
+		{
+			name:     "bare tvdb url",
+			content:  "http://thetvdb.com/?tab=series&id=81189",
+			expected: &NFO{IDs: MediaIDs{TVDB: 81189}},
+		},
+		{
+			name:    "release notes",
+			content: "Release notes\nVideo: x264 1080p\n",
+		},
+		{
+			name:    "empty movie",
+			content: `<movie><title></title></movie>`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "movie.nfo")
+			if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			nfo, err := ParseNFO(path)
+			if test.expected == nil {
+				if err == nil {
+					t.Errorf("got %+v, want an error", nfo)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.expected.Path = path
+			if !reflect.DeepEqual(nfo, test.expected) {
+				t.Errorf("got %+v, want %+v", nfo, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseNFOMissingFile(t *testing.T) {
+	if _, err := ParseNFO(filepath.Join(t.TempDir(), "movie.nfo")); err == nil {
+		t.Error("got no error for a missing file")
+	}
+}
@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DuplicatePolicy tells what to do when a new file is found for a movie or an episode already in the library
+type DuplicatePolicy string
+
+const (
+	DuplicatePolicyReplace  DuplicatePolicy = "replace"  // The new file always replaces the indexed one
+	DuplicatePolicyBest     DuplicatePolicy = "best"     // The file of best quality is kept, the indexed one on a tie
+	DuplicatePolicyExisting DuplicatePolicy = "existing" // The indexed file is always kept
+	DuplicatePolicyBoth     DuplicatePolicy = "both"     // Both files are kept as versions of a movie unless they share their label, TV episodes having a single file
+)
+
+// ParseDuplicatePolicy returns the duplicate policy of the given name (e.g. "best"), failing on an unknown one
+func ParseDuplicatePolicy(name string) (DuplicatePolicy, error) {
+	switch policy := DuplicatePolicy(strings.ToLower(strings.TrimSpace(name))); policy {
+	case DuplicatePolicyReplace, DuplicatePolicyBest, DuplicatePolicyExisting, DuplicatePolicyBoth:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown duplicate policy '%s'", name)
+}
+
+var sourceRanks = map[string]int{ // rank of the release sources, from the worst to the best
+	"CAM":      1,
+	"TELESYNC": 2,
+	"SCREENER": 3,
+	"DVD":      4,
+	"HDRip":    5,
+	"HDTV":     6,
+	"HDLight":  6,
+	"WEBRip":   7,
+	"WEB-DL":   8,
+	"BluRay":   9,
+	"Remux":    10,
+}
+
+var codecEfficiencies = map[string]float64{ // quality of the video codecs at equal bitrate, relative to H.264
+	"MPEG2VIDEO": 0.5,
+	"MPEG4":      0.7,
+	"MSMPEG4V3":  0.7,
+	"VC1":        0.9,
+	"H264":       1,
+	"VP9":        1.4,
+	"HEVC":       1.5,
+	"AV1":        1.8,
+}
+
+var lowGradeSources = map[string]bool{ // sources recorded in theaters or from promotional copies, worse than any release whatever their resolution
+	"CAM":      true,
+	"TELESYNC": true,
+	"SCREENER": true,
+}
+
+// bitrateMargin is the relative difference under which two bitrates are considered equal
+const bitrateMargin = 0.1
+
+// Quality holds what tells apart two files of the same movie or episode, from probe data and release tags
+type Quality struct {
+	Width      int    // Width of the video in pixels, 0 if unknown
+	Height     int    // Height of the video in pixels, 0 if unknown
+	Bitrate    int64  // Overall bitrate in bits per second, 0 if unknown
+	Codec      string // Probed video codec (e.g. H264, HEVC)
+	Source     string // Source of the release (e.g. BluRay, WEB-DL, CAM)
+	Resolution string // Resolution tag of the release (e.g. 1080p), standing in for an unknown video size
+}
+
+// NewQuality returns the quality of a media file from its probe data and its release tags
+func NewQuality(mediaData MediaData, release Release) Quality {
+	return Quality{
+		Width:      mediaData.Width,
+		Height:     mediaData.Height,
+		Bitrate:    mediaData.Bitrate,
+		Codec:      mediaData.Codec,
+		Source:     release.Source,
+		Resolution: release.Resolution,
+	}
+}
+
+// parseResolution returns the height of a release resolution tag (e.g. 1080p), 0 if unknown
+func parseResolution(resolution string) int {
+	matches := releaseResolutionRegex.FindStringSubmatch(strings.ToLower(resolution))
+	if matches == nil {
+		return 0
+	}
+	height, _ := strconv.Atoi(matches[1])
+	return height
+}
+
+// String returns a short description of the quality, e.g. 1080p BluRay HEVC 8.2 Mb/s
+func (q Quality) String() string {
+	var parts = make([]string, 0, 4)
+	if resolution := q.resolution(); resolution > 0 {
+		parts = append(parts, fmt.Sprintf("%dp", resolution))
+	}
+	if q.Source != "" {
+		parts = append(parts, q.Source)
+	}
+	if q.Codec != "" {
+		parts = append(parts, q.Codec)
+	}
+	if q.Bitrate > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f Mb/s", float64(q.Bitrate)/1e6))
+	}
+	if len(parts) == 0 {
+		return "unknown quality"
+	}
+	return strings.Join(parts, " ")
+}
+
+// VersionLabel returns the label telling apart the versions of a movie, made of its edition and its resolution (e.g. Director's Cut 2160p)
+func VersionLabel(edition string, quality Quality) string {
+	var parts = make([]string, 0, 2)
+	if edition != "" {
+		parts = append(parts, edition)
+	}
+	if resolution := quality.resolution(); resolution > 0 {
+		parts = append(parts, fmt.Sprintf("%dp", resolution))
+	}
+	if len(parts) == 0 {
+		return "Default"
+	}
+	return strings.Join(parts, " ")
+}
+
+// CompareQuality returns 1 if a is better than b, -1 if b is better than a and 0 if they can't be told apart.
+// A low grade source (CAM, TELESYNC, SCREENER) loses to any other source first, then the resolution is compared,
+// then the source, then the bitrate weighted by the efficiency of the codec. A criterion unknown for either file is skipped.
+func CompareQuality(a, b Quality) int {
+	if result := compareKnown(a.grade(), b.grade()); result != 0 {
+		return result
+	}
+	if result := compareKnown(a.resolution(), b.resolution()); result != 0 {
+		return result
+	}
+	if result := compareKnown(sourceRanks[a.Source], sourceRanks[b.Source]); result != 0 {
+		return result
+	}
+	aBitrate, bBitrate := a.effectiveBitrate(), b.effectiveBitrate()
+	if aBitrate == 0 || bBitrate == 0 {
+		return 0
+	}
+	if aBitrate > bBitrate*(1+bitrateMargin) {
+		return 1
+	}
+	if bBitrate > aBitrate*(1+bitrateMargin) {
+		return -1
+	}
+	return 0
+}
+
+// resolution returns the standard vertical resolution of the video, using the width as well for the videos cropped to a wider ratio
+func (q Quality) resolution() int {
+	var height = q.Height
+	if height == 0 {
+		height = parseResolution(q.Resolution)
+	}
+	switch {
+	case q.Width >= 3200 || height >= 1800:
+		return 2160
+	case q.Width >= 1800 || height >= 900:
+		return 1080
+	case q.Width >= 1200 || height >= 640:
+		return 720
+	case height >= 540:
+		return 576
+	case height > 0:
+		return 480
+	}
+	return 0
+}
+
+// grade returns 1 for a low grade source, 2 for any other known source and 0 if the source is unknown
+func (q Quality) grade() int {
+	switch {
+	case lowGradeSources[q.Source]:
+		return 1
+	case sourceRanks[q.Source] > 0:
+		return 2
+	}
+	return 0
+}
+
+// effectiveBitrate returns the bitrate of the file as if it was encoded in H.264, 0 if unknown
+func (q Quality) effectiveBitrate() float64 {
+	efficiency, found := codecEfficiencies[q.Codec]
+	if !found {
+		efficiency = 1
+	}
+	return float64(q.Bitrate) * efficiency
+}
+
+// compareKnown compares two ranks, 0 meaning unknown
+func compareKnown(a, b int) int {
+	if a == 0 || b == 0 || a == b {
+		return 0
+	}
+	if a > b {
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,83 @@
+package pkg
+
+import "testing"
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	var tests = []struct {
+		name     string
+		expected DuplicatePolicy
+		ok       bool
+	}{
+		{name: "replace", expected: DuplicatePolicyReplace, ok: true},
+		{name: "best", expected: DuplicatePolicyBest, ok: true},
+		{name: " Existing ", expected: DuplicatePolicyExisting, ok: true},
+		{name: "BOTH", expected: DuplicatePolicyBoth, ok: true},
+		{name: ""},
+		{name: "keep"},
+	}
+	for _, test := range tests {
+		policy, err := ParseDuplicatePolicy(test.name)
+		if policy != test.expected || (err == nil) != test.ok {
+			t.Errorf("ParseDuplicatePolicy(%q) = %q, %v, want %q, ok %t", test.name, policy, err, test.expected, test.ok)
+		}
+	}
+}
+
+func TestCompareQuality(t *testing.T) {
+	var tests = []struct {
+		name     string
+		a        Quality
+		b        Quality
+		expected int
+	}{
+		{name: "higher resolution", a: Quality{Height: 2160}, b: Quality{Height: 1080}, expected: 1},
+		{name: "lower resolution", a: Quality{Height: 720, Source: "Remux"}, b: Quality{Height: 1080, Source: "WEBRip"}, expected: -1},
+		{name: "resolution tag", a: Quality{Resolution: "1080p"}, b: Quality{Width: 1280, Height: 536}, expected: 1},
+		{name: "better source", a: Quality{Height: 1080, Source: "BluRay"}, b: Quality{Height: 1080, Source: "WEB-DL"}, expected: 1},
+		{name: "equal sources", a: Quality{Height: 1080, Source: "HDTV"}, b: Quality{Height: 1080, Source: "HDLight"}},
+		{name: "cam below lower resolution", a: Quality{Height: 2160, Source: "CAM"}, b: Quality{Height: 480, Source: "DVD"}, expected: -1},
+		{name: "screener below lower resolution", a: Quality{Height: 720, Source: "HDTV"}, b: Quality{Height: 1080, Source: "SCREENER"}, expected: 1},
+		{name: "low grade sources", a: Quality{Height: 1080, Source: "TELESYNC"}, b: Quality{Height: 1080, Source: "CAM"}, expected: 1},
+		{name: "unknown source", a: Quality{Height: 720}, b: Quality{Height: 1080, Source: "CAM"}, expected: -1},
+		{name: "higher bitrate", a: Quality{Height: 1080, Bitrate: 10_000_000}, b: Quality{Height: 1080, Bitrate: 8_000_000}, expected: 1},
+		{name: "bitrate within margin", a: Quality{Height: 1080, Bitrate: 10_500_000}, b: Quality{Height: 1080, Bitrate: 10_000_000}},
+		{name: "efficient codec", a: Quality{Height: 1080, Bitrate: 6_000_000, Codec: "HEVC"}, b: Quality{Height: 1080, Bitrate: 8_000_000, Codec: "H264"}, expected: 1},
+		{name: "unknown bitrate", a: Quality{Height: 1080, Bitrate: 10_000_000}, b: Quality{Height: 1080}},
+		{name: "unknown quality", a: Quality{}, b: Quality{Height: 1080, Source: "BluRay"}},
+	}
+	for _, test := range tests {
+		if result := CompareQuality(test.a, test.b); result != test.expected {
+			t.Errorf("%s: CompareQuality(%+v, %+v) = %d, want %d", test.name, test.a, test.b, result, test.expected)
+		}
+		if result := CompareQuality(test.b, test.a); result != -test.expected {
+			t.Errorf("%s: CompareQuality(%+v, %+v) = %d, want %d", test.name, test.b, test.a, result, -test.expected)
+		}
+	}
+}
+
+func TestQualityResolution(t *testing.T) {
+	var tests = []struct {
+		quality  Quality
+		expected int
+	}{
+		{quality: Quality{Width: 3840, Height: 2160}, expected: 2160},
+		{quality: Quality{Width: 3840, Height: 1600}, expected: 2160},
+		{quality: Quality{Width: 1920, Height: 1080}, expected: 1080},
+		{quality: Quality{Width: 1920, Height: 800}, expected: 1080},
+		{quality: Quality{Width: 1280, Height: 720}, expected: 720},
+		{quality: Quality{Width: 1280, Height: 536}, expected: 720},
+		{quality: Quality{Width: 720, Height: 576}, expected: 576},
+		{quality: Quality{Width: 720, Height: 480}, expected: 480},
+		{quality: Quality{Width: 640, Height: 360}, expected: 480},
+		{quality: Quality{Resolution: "2160p"}, expected: 2160},
+		{quality: Quality{Resolution: "720P"}, expected: 720},
+		{quality: Quality{Width: 1920, Height: 1080, Resolution: "720p"}, expected: 1080},
+		{quality: Quality{Resolution: "HD"}},
+		{quality: Quality{}},
+	}
+	for _, test := range tests {
+		if result := test.quality.resolution(); result != test.expected {
+			t.Errorf("resolution of %+v = %d, want %d", test.quality, result, test.expected)
+		}
+	}
+}
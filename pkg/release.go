@@ -0,0 +1,462 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Release holds the information carried by the name of a release,
+// e.g. "Movie.Name.2019.2160p.UHD.BluRay.x265.HDR.DTS-HD-GROUP.mkv"
+type Release struct {
+	Title        string   // Title of the movie or TV show (e.g. Movie Name)
+	Year         string   // Release year (e.g. 2019)
+	Season       int      // Season number, 0 if none
+	Episode      int      // Episode number, 0 if none
+	Resolution   string   // Vertical resolution (e.g. 2160p, 1080p, 720p)
+	Source       string   // Source of the release (e.g. BluRay, Remux, WEB-DL, WEBRip, HDTV, DVD)
+	VideoCodec   string   // Video codec (e.g. H.264, H.265, XviD)
+	AudioCodec   string   // Audio codec (e.g. DTS-HD, TrueHD Atmos, E-AC3, AAC)
+	HDR          bool     // Whether the video is HDR (HDR10, HDR10+, Dolby Vision, HLG)
+	Edition      string   // Edition of the movie (e.g. Director's Cut, Extended, IMAX)
+	ReleaseGroup string   // Group who made the release
+	Languages    []string // Language tags (e.g. MULTI, FRENCH, VOSTFR)
+}
+
+type releaseTagKind int
+
+const (
+	resolutionTag releaseTagKind = iota
+	sourceTag
+	videoCodecTag
+	audioCodecTag
+	hdrTag
+	languageTag
+	otherTag
+)
+
+// releaseTag is a known token of a release name.
+// Weak tags are also common words, so they can't end the title of a release on their own.
+type releaseTag struct {
+	kind  releaseTagKind
+	value string
+	weak  bool
+}
+
+var releaseTags = map[string]releaseTag{
+	"4k":         {kind: resolutionTag, value: "2160p"},
+	"uhd":        {kind: resolutionTag, value: "2160p"},
+	"bluray":     {kind: sourceTag, value: "BluRay"},
+	"blu-ray":    {kind: sourceTag, value: "BluRay"},
+	"bdrip":      {kind: sourceTag, value: "BluRay"},
+	"brrip":      {kind: sourceTag, value: "BluRay"},
+	"bd":         {kind: sourceTag, value: "BluRay", weak: true},
+	"remux":      {kind: sourceTag, value: "Remux"},
+	"bdremux":    {kind: sourceTag, value: "Remux"},
+	"web-dl":     {kind: sourceTag, value: "WEB-DL"},
+	"webdl":      {kind: sourceTag, value: "WEB-DL"},
+	"web":        {kind: sourceTag, value: "WEB-DL", weak: true},
+	"webrip":     {kind: sourceTag, value: "WEBRip"},
+	"web-rip":    {kind: sourceTag, value: "WEBRip"},
+	"hdtv":       {kind: sourceTag, value: "HDTV"},
+	"pdtv":       {kind: sourceTag, value: "HDTV"},
+	"hdtvrip":    {kind: sourceTag, value: "HDTV"},
+	"dvdrip":     {kind: sourceTag, value: "DVD"},
+	"dvd":        {kind: sourceTag, value: "DVD"},
+	"dvdr":       {kind: sourceTag, value: "DVD"},
+	"hdrip":      {kind: sourceTag, value: "HDRip"},
+	"hdlight":    {kind: sourceTag, value: "HDLight"},
+	"cam":        {kind: sourceTag, value: "CAM", weak: true},
+	"camrip":     {kind: sourceTag, value: "CAM"},
+	"hdcam":      {kind: sourceTag, value: "CAM"},
+	"telesync":   {kind: sourceTag, value: "TELESYNC"},
+	"hdts":       {kind: sourceTag, value: "TELESYNC"},
+	"dvdscr":     {kind: sourceTag, value: "SCREENER"},
+	"screener":   {kind: sourceTag, value: "SCREENER"},
+	"x264":       {kind: videoCodecTag, value: "H.264"},
+	"h264":       {kind: videoCodecTag, value: "H.264"},
+	"avc":        {kind: videoCodecTag, value: "H.264", weak: true},
+	"x265":       {kind: videoCodecTag, value: "H.265"},
+	"h265":       {kind: videoCodecTag, value: "H.265"},
+	"hevc":       {kind: videoCodecTag, value: "H.265"},
+	"xvid":       {kind: videoCodecTag, value: "XviD"},
+	"divx":       {kind: videoCodecTag, value: "DivX"},
+	"av1":        {kind: videoCodecTag, value: "AV1"},
+	"vp9":        {kind: videoCodecTag, value: "VP9"},
+	"vc1":        {kind: videoCodecTag, value: "VC-1"},
+	"vc-1":       {kind: videoCodecTag, value: "VC-1"},
+	"mpeg2":      {kind: videoCodecTag, value: "MPEG-2"},
+	"dts-hd":     {kind: audioCodecTag, value: "DTS-HD"},
+	"dtshd":      {kind: audioCodecTag, value: "DTS-HD"},
+	"dts-x":      {kind: audioCodecTag, value: "DTS:X"},
+	"dtsx":       {kind: audioCodecTag, value: "DTS:X"},
+	"dts":        {kind: audioCodecTag, value: "DTS"},
+	"truehd":     {kind: audioCodecTag, value: "TrueHD"},
+	"atmos":      {kind: audioCodecTag, value: "Atmos"},
+	"eac3":       {kind: audioCodecTag, value: "E-AC3"},
+	"ddp":        {kind: audioCodecTag, value: "E-AC3"},
+	"ac3":        {kind: audioCodecTag, value: "AC3"},
+	"dd":         {kind: audioCodecTag, value: "AC3", weak: true},
+	"aac":        {kind: audioCodecTag, value: "AAC"},
+	"flac":       {kind: audioCodecTag, value: "FLAC"},
+	"mp3":        {kind: audioCodecTag, value: "MP3"},
+	"opus":       {kind: audioCodecTag, value: "Opus"},
+	"lpcm":       {kind: audioCodecTag, value: "LPCM"},
+	"hdr":        {kind: hdrTag, value: "HDR"},
+	"hdr10":      {kind: hdrTag, value: "HDR"},
+	"hdr10plus":  {kind: hdrTag, value: "HDR"},
+	"dv":         {kind: hdrTag, value: "HDR", weak: true},
+	"dovi":       {kind: hdrTag, value: "HDR"},
+	"hlg":        {kind: hdrTag, value: "HDR"},
+	"multi":      {kind: languageTag, value: "MULTI", weak: true},
+	"french":     {kind: languageTag, value: "FRENCH", weak: true},
+	"truefrench": {kind: languageTag, value: "TRUEFRENCH"},
+	"vff":        {kind: languageTag, value: "VFF"},
+	"vfq":        {kind: languageTag, value: "VFQ"},
+	"vfi":        {kind: languageTag, value: "VFI"},
+	"vf":         {kind: languageTag, value: "VF"},
+	"vof":        {kind: languageTag, value: "VOF"},
+	"vostfr":     {kind: languageTag, value: "VOSTFR"},
+	"vost":       {kind: languageTag, value: "VOST"},
+	"subfrench":  {kind: languageTag, value: "VOSTFR"},
+	"vo":         {kind: languageTag, value: "VO", weak: true},
+	"english":    {kind: languageTag, value: "ENGLISH", weak: true},
+	"german":     {kind: languageTag, value: "GERMAN", weak: true},
+	"spanish":    {kind: languageTag, value: "SPANISH", weak: true},
+	"italian":    {kind: languageTag, value: "ITALIAN", weak: true},
+	"japanese":   {kind: languageTag, value: "JAPANESE", weak: true},
+	"proper":     {kind: otherTag, weak: true},
+	"repack":     {kind: otherTag, weak: true},
+	"internal":   {kind: otherTag, weak: true},
+	"limited":    {kind: otherTag, weak: true},
+	"10bit":      {kind: otherTag},
+	"8bit":       {kind: otherTag},
+	"custom":     {kind: otherTag, weak: true},
+}
+
+// releaseEditions maps the first word of an edition to its name.
+// Editions ending with Cut, Edition or Version only match when followed by one of these words.
+var releaseEditions = map[string]string{
+	"extended":    "Extended",
+	"unrated":     "Unrated",
+	"uncut":       "Uncut",
+	"remastered":  "Remastered",
+	"theatrical":  "Theatrical",
+	"imax":        "IMAX",
+	"criterion":   "Criterion",
+	"directors":   "Director's Cut",
+	"director's":  "Director's Cut",
+	"final":       "Final Cut",
+	"special":     "Special Edition",
+	"ultimate":    "Ultimate Edition",
+	"collectors":  "Collector's Edition",
+	"collector's": "Collector's Edition",
+}
+
+var releaseEditionSuffixes = map[string]bool{
+	"cut":     true,
+	"edition": true,
+	"version": true,
+}
+
+var (
+	releaseExtensionRegex    = regexp.MustCompile(`^\.[A-Za-z0-9]{2,4}$`)
+	releaseLeadingGroup      = regexp.MustCompile(`^\s*\[([^\]]+)\]\s*`)
+	releaseSeparatorRegex    = regexp.MustCompile(`[\s._\[\](){},]+`)
+	releaseTrailingDigits    = regexp.MustCompile(`\d+$`)
+	releaseResolutionRegex   = regexp.MustCompile(`^(\d{3,4})([pi])$`)
+	releaseDimensionRegex    = regexp.MustCompile(`^\d{3,4}x(\d{3,4})$`)
+	releaseYearRegex         = regexp.MustCompile(`^(19|20)\d{2}$`)
+	releaseEpisodeRegex      = regexp.MustCompile(`^s(\d{1,2})e(\d{1,3})`)
+	releaseCrossEpisodeRegex = regexp.MustCompile(`^(\d{1,2})x(\d{2,3})$`)
+	releaseSeasonRegex       = regexp.MustCompile(`^s(\d{1,2})$`)
+	releaseNormalizers       = []struct {
+		regex       *regexp.Regexp
+		replacement string
+	}{
+		{regexp.MustCompile(`(?i)\bh\.(26[45])\b`), "h$1"},
+		{regexp.MustCompile(`(?i)\bdd\+`), "ddp"},
+		{regexp.MustCompile(`(?i)\bhdr10\+`), "hdr10plus"},
+		{regexp.MustCompile(`(?i)\bdolby[ .]vision\b`), "dovi"},
+		{regexp.MustCompile(`(?i)\bdts[ .-]?hd[ .-]?ma\b`), "dts-hd"},
+	}
+)
+
+// ParseRelease extracts the title, year, season, episode and the quality, source and language tags of a release name.
+func ParseRelease(filename string) Release {
+	name := filename
+	if extension := filepath.Ext(name); releaseExtensionRegex.MatchString(extension) && !isReleaseTag(extension[1:]) {
+		name = strings.TrimSuffix(name, extension)
+	}
+	return parseReleaseName(name)
+}
+
+// ParseReleaseFolder is ParseRelease for folder names, which have no extension to strip (e.g. "Some.Movie.2019")
+func ParseReleaseFolder(folder string) Release {
+	return parseReleaseName(folder)
+}
+
+func parseReleaseName(name string) Release {
+	var release Release
+
+	name = strings.TrimSpace(stripMediaIDs(name))
+	if matches := releaseLeadingGroup.FindStringSubmatch(name); matches != nil {
+		release.ReleaseGroup = strings.TrimSpace(matches[1])
+		name = name[len(matches[0]):]
+	}
+	for _, normalizer := range releaseNormalizers {
+		name = normalizer.regex.ReplaceAllString(name, normalizer.replacement)
+	}
+
+	tokens := make([]string, 0)
+	for _, token := range releaseSeparatorRegex.Split(name, -1) {
+		token = strings.Trim(token, "-")
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	titleEnd, yearIndex := findReleaseTitleEnd(tokens)
+	if yearIndex >= 0 {
+		release.Year = tokens[yearIndex]
+	}
+	release.Title = strings.Join(tokens[:titleEnd], " ")
+
+	for i := titleEnd; i < len(tokens); i++ {
+		token := tokens[i]
+		if i == yearIndex {
+			continue
+		}
+		if release.Year == "" && isPlausibleYear(token) {
+			release.Year = token
+			continue
+		}
+		if parseReleaseEpisode(token, &release) {
+			continue
+		}
+		if edition, consumed := parseReleaseEdition(tokens, i); edition != "" {
+			release.addEdition(edition)
+			i += consumed
+			continue
+		}
+		if release.applyTag(token) {
+			continue
+		}
+		// Hyphenated tokens mix tags with the release group, e.g. x264-GROUP or DTS-HD-GROUP
+		if parts := strings.Split(token, "-"); len(parts) > 1 {
+			for j, part := range parts {
+				if j+1 < len(parts) && release.applyTag(part+"-"+parts[j+1]) {
+					parts[j+1] = ""
+					continue
+				}
+				if part == "" || release.applyTag(part) {
+					continue
+				}
+				if j == len(parts)-1 && i == len(tokens)-1 && release.ReleaseGroup == "" {
+					release.ReleaseGroup = part
+				}
+			}
+		}
+	}
+	return release
+}
+
+// String returns a short description of the release quality, e.g. "2160p BluRay H.265 HDR DTS-HD"
+func (r Release) String() string {
+	var parts = make([]string, 0)
+	for _, part := range []string{r.Resolution, r.Source, r.VideoCodec} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if r.HDR {
+		parts = append(parts, "HDR")
+	}
+	for _, part := range []string{r.AudioCodec, r.Edition} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if r.ReleaseGroup != "" {
+		parts = append(parts, fmt.Sprintf("[%s]", r.ReleaseGroup))
+	}
+	return strings.Join(parts, " ")
+}
+
+// findReleaseTitleEnd returns the index of the first token following the title and the index of the year token, -1 if none.
+// The title ends with the year, or with the first strong tag if the year comes later or is missing.
+// When several years follow each other (e.g. "Blade Runner 2049 2017"), the last one is the release year.
+func findReleaseTitleEnd(tokens []string) (int, int) {
+	var yearIndex = -1
+	for i, token := range tokens {
+		if i > 0 && isPlausibleYear(token) {
+			yearIndex = i
+			continue
+		}
+		// Season and episode numbers can't be part of the title, even at its start (e.g. "S02E02.mkv" in a show folder)
+		if (i > 0 && isStrongReleaseToken(token)) || (i == 0 && isEpisodeToken(token)) {
+			if yearIndex >= 0 {
+				return yearIndex, yearIndex
+			}
+			return i, -1
+		}
+	}
+	if yearIndex >= 0 {
+		return yearIndex, yearIndex
+	}
+	// Without any strong tag, only upper case weak tags (e.g. FRENCH, MULTI) end the title
+	for i, token := range tokens {
+		if i > 0 && token == strings.ToUpper(token) && isReleaseTag(token) {
+			return i, -1
+		}
+	}
+	return len(tokens), -1
+}
+
+func isEpisodeToken(token string) bool {
+	lower := strings.ToLower(token)
+	return releaseEpisodeRegex.MatchString(lower) || releaseCrossEpisodeRegex.MatchString(lower) || releaseSeasonRegex.MatchString(lower)
+}
+
+func isStrongReleaseToken(token string) bool {
+	if isEpisodeToken(token) {
+		return true
+	}
+	lower := strings.ToLower(token)
+	if releaseResolutionRegex.MatchString(lower) || releaseDimensionRegex.MatchString(lower) {
+		return true
+	}
+	tag, ok := lookupReleaseTag(lower)
+	if !ok && strings.Contains(lower, "-") {
+		tag, ok = lookupReleaseTag(lower[:strings.Index(lower, "-")])
+	}
+	return ok && !tag.weak
+}
+
+func isReleaseTag(token string) bool {
+	_, ok := lookupReleaseTag(strings.ToLower(token))
+	return ok
+}
+
+// lookupReleaseTag finds a tag, ignoring trailing digits such as audio channels (e.g. DDP5, AAC2) when needed
+func lookupReleaseTag(lower string) (releaseTag, bool) {
+	if tag, ok := releaseTags[lower]; ok {
+		return tag, true
+	}
+	trimmed := releaseTrailingDigits.ReplaceAllString(lower, "")
+	if trimmed == "" || trimmed == lower {
+		return releaseTag{}, false
+	}
+	tag, ok := releaseTags[trimmed]
+	return tag, ok
+}
+
+// isPlausibleYear reports whether the token is a year between 1900 and next year
+func isPlausibleYear(token string) bool {
+	if !releaseYearRegex.MatchString(token) {
+		return false
+	}
+	year, _ := strconv.Atoi(token)
+	return year <= time.Now().Year()+1
+}
+
+func parseReleaseEpisode(token string, release *Release) bool {
+	lower := strings.ToLower(token)
+	var matches []string
+	if matches = releaseEpisodeRegex.FindStringSubmatch(lower); matches == nil {
+		if matches = releaseCrossEpisodeRegex.FindStringSubmatch(lower); matches == nil {
+			if matches = releaseSeasonRegex.FindStringSubmatch(lower); matches == nil {
+				return false
+			}
+		}
+	}
+	release.Season, _ = strconv.Atoi(matches[1])
+	if len(matches) > 2 {
+		release.Episode, _ = strconv.Atoi(matches[2])
+	}
+	return true
+}
+
+// parseReleaseEdition returns the edition starting at the given token and the number of extra tokens it spans
+func parseReleaseEdition(tokens []string, i int) (string, int) {
+	lower := strings.ToLower(tokens[i])
+	edition, ok := releaseEditions[lower]
+	if !ok {
+		return "", 0
+	}
+	var followedBySuffix = i+1 < len(tokens) && releaseEditionSuffixes[strings.ToLower(tokens[i+1])]
+	if strings.Contains(edition, " ") {
+		if !followedBySuffix {
+			return "", 0
+		}
+		return edition, 1
+	}
+	if followedBySuffix {
+		return edition, 1
+	}
+	return edition, 0
+}
+
+func (r *Release) addEdition(edition string) {
+	if r.Edition == "" {
+		r.Edition = edition
+		return
+	}
+	if !strings.Contains(r.Edition, edition) {
+		r.Edition += " " + edition
+	}
+}
+
+// applyTag stores the value of a known tag in the release, returning false if the token isn't a tag
+func (r *Release) applyTag(token string) bool {
+	lower := strings.ToLower(token)
+	if matches := releaseResolutionRegex.FindStringSubmatch(lower); matches != nil {
+		r.Resolution = matches[1] + matches[2]
+		return true
+	}
+	if matches := releaseDimensionRegex.FindStringSubmatch(lower); matches != nil {
+		r.Resolution = matches[1] + "p"
+		return true
+	}
+	tag, ok := lookupReleaseTag(lower)
+	if !ok {
+		return false
+	}
+	switch tag.kind {
+	case resolutionTag:
+		if r.Resolution == "" {
+			r.Resolution = tag.value
+		}
+	case sourceTag:
+		// A remux keeps its BluRay source tag, the remux is the most relevant information
+		if r.Source == "" || tag.value == "Remux" {
+			r.Source = tag.value
+		}
+	case videoCodecTag:
+		if r.VideoCodec == "" {
+			r.VideoCodec = tag.value
+		}
+	case audioCodecTag:
+		switch {
+		case r.AudioCodec == "":
+			r.AudioCodec = tag.value
+		case tag.value == "Atmos":
+			r.AudioCodec += " Atmos"
+		case r.AudioCodec == "Atmos":
+			r.AudioCodec = tag.value + " Atmos"
+		}
+	case hdrTag:
+		r.HDR = true
+	case languageTag:
+		for _, language := range r.Languages {
+			if language == tag.value {
+				return true
+			}
+		}
+		r.Languages = append(r.Languages, tag.value)
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRelease(t *testing.T) {
+	var tests = []struct {
+		filename string
+		expected Release
+	}{
+		{
+			filename: "Movie.Name.2019.2160p.UHD.BluRay.x265.HDR.DTS-HD-GROUP.mkv",
+			expected: Release{Title: "Movie Name", Year: "2019", Resolution: "2160p", Source: "BluRay", VideoCodec: "H.265", HDR: true, AudioCodec: "DTS-HD", ReleaseGroup: "GROUP"},
+		},
+		{
+			filename: "The.Matrix.1999.1080p.BluRay.x264-SPARKS.mkv",
+			expected: Release{Title: "The Matrix", Year: "1999", Resolution: "1080p", Source: "BluRay", VideoCodec: "H.264", ReleaseGroup: "SPARKS"},
+		},
+		{
+			filename: "Inception.2010.720p.BRRip.XviD.AC3-FLAWL3SS.avi",
+			expected: Release{Title: "Inception", Year: "2010", Resolution: "720p", Source: "BluRay", VideoCodec: "XviD", AudioCodec: "AC3", ReleaseGroup: "FLAWL3SS"},
+		},
+		{
+			filename: "Dune.Part.Two.2024.2160p.WEB-DL.DDP5.1.Atmos.DV.HDR.H.265-FLUX.mkv",
+			expected: Release{Title: "Dune Part Two", Year: "2024", Resolution: "2160p", Source: "WEB-DL", AudioCodec: "E-AC3 Atmos", HDR: true, VideoCodec: "H.265", ReleaseGroup: "FLUX"},
+		},
+		{
+			filename: "Oppenheimer.2023.1080p.WEBRip.x265.10bit.AAC5.1-LAMA.mp4",
+			expected: Release{Title: "Oppenheimer", Year: "2023", Resolution: "1080p", Source: "WEBRip", VideoCodec: "H.265", AudioCodec: "AAC", ReleaseGroup: "LAMA"},
+		},
+		{
+			filename: "Le.Fabuleux.Destin.d'Amelie.Poulain.2001.FRENCH.1080p.BluRay.x264-LOST.mkv",
+			expected: Release{Title: "Le Fabuleux Destin d'Amelie Poulain", Year: "2001", Languages: []string{"FRENCH"}, Resolution: "1080p", Source: "BluRay", VideoCodec: "H.264", ReleaseGroup: "LOST"},
+		},
+		{
+			filename: "Intouchables.2011.MULTi.TRUEFRENCH.1080p.BluRay.x264.DTS-HDMA.mkv",
+			expected: Release{Title: "Intouchables", Year: "2011", Languages: []string{"MULTI", "TRUEFRENCH"}, Resolution: "1080p", Source: "BluRay", VideoCodec: "H.264", AudioCodec: "DTS-HD"},
+		},
+		{
+			filename: "La.Haine.1995.VOSTFR.720p.HDLight.x264.mkv",
+			expected: Release{Title: "La Haine", Year: "1995", Languages: []string{"VOSTFR"}, Resolution: "720p", Source: "HDLight", VideoCodec: "H.264"},
+		},
+		{
+			filename: "Blade.Runner.2049.2017.2160p.UHD.BluRay.REMUX.HDR.HEVC.TrueHD.Atmos.7.1-EPSiLON.mkv",
+			expected: Release{Title: "Blade Runner 2049", Year: "2017", Resolution: "2160p", Source: "Remux", HDR: true, VideoCodec: "H.265", AudioCodec: "TrueHD Atmos", ReleaseGroup: "EPSiLON"},
+		},
+		{
+			filename: "1917.2019.1080p.BluRay.x264-SPARKS.mkv",
+			expected: Release{Title: "1917", Year: "2019", Resolution: "1080p", Source: "BluRay", VideoCodec: "H.264", ReleaseGroup: "SPARKS"},
+		},
+		{
+			filename: "2001.A.Space.Odyssey.1968.REMASTERED.1080p.BluRay.x264.mkv",
+			expected: Release{Title: "2001 A Space Odyssey", Year: "1968", Edition: "Remastered", Resolution: "1080p", Source: "BluRay", VideoCodec: "H.264"},
+		},
+		{
+			filename: "Apocalypse.Now.1979.Final.Cut.1080p.BluRay.x264.mkv",
+			expected: Release{Title: "Apocalypse Now", Year: "1979", Edition: "Final Cut", Resolution: "1080p", Source: "BluRay", VideoCodec: "H.264"},
+		},
+		{
+			filename: "Kingdom.of.Heaven.2005.Directors.Cut.720p.BluRay.DTS.x264-ESiR.mkv",
+			expected: Release{Title: "Kingdom of Heaven", Year: "2005", Edition: "Director's Cut", Resolution: "720p", Source: "BluRay", AudioCodec: "DTS", VideoCodec: "H.264", ReleaseGroup: "ESiR"},
+		},
+		{
+			filename: "The.Lord.of.the.Rings.The.Fellowship.of.the.Ring.2001.EXTENDED.1080p.BluRay.x264.mkv",
+			expected: Release{Title: "The Lord of the Rings The Fellowship of the Ring", Year: "2001", Edition: "Extended", Resolution: "1080p", Source: "BluRay", VideoCodec: "H.264"},
+		},
+		{
+			filename: "Aliens.1986.Special.Edition.1080p.BluRay.x264.mkv",
+			expected: Release{Title: "Aliens", Year: "1986", Edition: "Special Edition", Resolution: "1080p", Source: "BluRay", VideoCodec: "H.264"},
+		},
+		{
+			filename: "Tenet.2020.IMAX.2160p.WEB-DL.DDP5.1.HDR10plus.HEVC.mkv",
+			expected: Release{Title: "Tenet", Year: "2020", Edition: "IMAX", Resolution: "2160p", Source: "WEB-DL", AudioCodec: "E-AC3", HDR: true, VideoCodec: "H.265"},
+		},
+		{
+			filename: "Avatar (2009) [1080p] [BluRay] [5.1] [YTS.MX].mp4",
+			expected: Release{Title: "Avatar", Year: "2009", Resolution: "1080p", Source: "BluRay"},
+		},
+		{
+			filename: "Parasite (2019) 2160p UHD BluRay HDR10+ DD+ 7.1.mkv",
+			expected: Release{Title: "Parasite", Year: "2019", Resolution: "2160p", Source: "BluRay", HDR: true, AudioCodec: "E-AC3"},
+		},
+		{
+			filename: "Joker.2019.HDR.2160p.WEB.H265-NAISU.mkv",
+			expected: Release{Title: "Joker", Year: "2019", HDR: true, Resolution: "2160p", Source: "WEB-DL", VideoCodec: "H.265", ReleaseGroup: "NAISU"},
+		},
+		{
+			filename: "Spider-Man.No.Way.Home.2021.1080p.WEBRip.x264.AAC-YTS.mp4",
+			expected: Release{Title: "Spider-Man No Way Home", Year: "2021", Resolution: "1080p", Source: "WEBRip", VideoCodec: "H.264", AudioCodec: "AAC", ReleaseGroup: "YTS"},
+		},
+		{
+			filename: "Charlottes.Web.2006.DVDRip.XviD-DiAMOND.avi",
+			expected: Release{Title: "Charlottes Web", Year: "2006", Source: "DVD", VideoCodec: "XviD", ReleaseGroup: "DiAMOND"},
+		},
+		{
+			filename: "The.French.Connection.1971.1080p.BluRay.FLAC.1.0.x264.mkv",
+			expected: Release{Title: "The French Connection", Year: "1971", Resolution: "1080p", Source: "BluRay", AudioCodec: "FLAC", VideoCodec: "H.264"},
+		},
+		{
+			filename: "Gladiator.2000.1080i.HDTV.MPEG2.mkv",
+			expected: Release{Title: "Gladiator", Year: "2000", Resolution: "1080i", Source: "HDTV", VideoCodec: "MPEG-2"},
+		},
+		{
+			filename: "Heat.1995.1920x1080.BDRemux.AVC.LPCM.mkv",
+			expected: Release{Title: "Heat", Year: "1995", Resolution: "1080p", Source: "Remux", VideoCodec: "H.264", AudioCodec: "LPCM"},
+		},
+		{
+			filename: "Movie.Name.2023.HDCAM.x264-GRP.mkv",
+			expected: Release{Title: "Movie Name", Year: "2023", Source: "CAM", VideoCodec: "H.264", ReleaseGroup: "GRP"},
+		},
+		{
+			filename: "Amelie FRENCH.mkv",
+			expected: Release{Title: "Amelie", Languages: []string{"FRENCH"}},
+		},
+		{
+			filename: "The French Dispatch.mkv",
+			expected: Release{Title: "The French Dispatch"},
+		},
+		{
+			filename: "Movie.1080p.2019.mkv",
+			expected: Release{Title: "Movie", Year: "2019", Resolution: "1080p"},
+		},
+		{
+			filename: "Some Movie.mp4",
+			expected: Release{Title: "Some Movie"},
+		},
+		{
+			filename: "Breaking.Bad.S05E14.720p.HDTV.x264-IMMERSE.mkv",
+			expected: Release{Title: "Breaking Bad", Season: 5, Episode: 14, Resolution: "720p", Source: "HDTV", VideoCodec: "H.264", ReleaseGroup: "IMMERSE"},
+		},
+		{
+			filename: "Game.of.Thrones.S08E03.The.Long.Night.1080p.AMZN.WEB-DL.DDP5.1.H.264-GoT.mkv",
+			expected: Release{Title: "Game of Thrones", Season: 8, Episode: 3, Resolution: "1080p", Source: "WEB-DL", AudioCodec: "E-AC3", VideoCodec: "H.264", ReleaseGroup: "GoT"},
+		},
+		{
+			filename: "The.Office.US.s02e01.DVDRip.XviD.avi",
+			expected: Release{Title: "The Office US", Season: 2, Episode: 1, Source: "DVD", VideoCodec: "XviD"},
+		},
+		{
+			filename: "Doctor.Who.2005.S13E01.1080p.HDTV.H264-SFM.mkv",
+			expected: Release{Title: "Doctor Who", Year: "2005", Season: 13, Episode: 1, Resolution: "1080p", Source: "HDTV", VideoCodec: "H.264", ReleaseGroup: "SFM"},
+		},
+		{
+			filename: "Friends 3x07 The Race Car Bed.mkv",
+			expected: Release{Title: "Friends", Season: 3, Episode: 7},
+		},
+		{
+			filename: "Lupin.S01E05.MULTi.1080p.NF.WEB-DL.x264-FRATERNiTY.mkv",
+			expected: Release{Title: "Lupin", Season: 1, Episode: 5, Languages: []string{"MULTI"}, Resolution: "1080p", Source: "WEB-DL", VideoCodec: "H.264", ReleaseGroup: "FRATERNiTY"},
+		},
+		{
+			filename: "Dark.S02.VOSTFR.1080p.WEBRip.x265.mkv",
+			expected: Release{Title: "Dark", Season: 2, Languages: []string{"VOSTFR"}, Resolution: "1080p", Source: "WEBRip", VideoCodec: "H.265"},
+		},
+		{
+			filename: "[SubsPlease] Jujutsu Kaisen S02E01 [1080p].mkv",
+			expected: Release{Title: "Jujutsu Kaisen", Season: 2, Episode: 1, Resolution: "1080p", ReleaseGroup: "SubsPlease"},
+		},
+		{
+			filename: "Chernobyl.S01E01.2160p.UHD.BluRay.x265.10bit.HDR.DTS-X.mkv",
+			expected: Release{Title: "Chernobyl", Season: 1, Episode: 1, Resolution: "2160p", Source: "BluRay", VideoCodec: "H.265", HDR: true, AudioCodec: "DTS:X"},
+		},
+		{
+			filename: "Movie.Name.2019.1080p.BluRay.x264",
+			expected: Release{Title: "Movie Name", Year: "2019", Resolution: "1080p", Source: "BluRay", VideoCodec: "H.264"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			release := ParseRelease(test.filename)
+			if !reflect.DeepEqual(release, test.expected) {
+				t.Errorf("ParseRelease(%q)\n got: %+v\nwant: %+v", test.filename, release, test.expected)
+			}
+		})
+	}
+}
+
+func TestReleaseString(t *testing.T) {
+	release := ParseRelease("Movie.Name.2019.2160p.UHD.BluRay.x265.HDR.DTS-HD-GROUP.mkv")
+	if expected := "2160p BluRay H.265 HDR DTS-HD [GROUP]"; release.String() != expected {
+		t.Errorf("String() = %q, want %q", release.String(), expected)
+	}
+}
@@ -1,11 +1,13 @@
 package pkg
 
 import (
+	"fmt"
 	"log"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"golang.org/x/text/transform"
@@ -18,13 +20,62 @@ var deleteRegexes = []*regexp.Regexp{
 }
 
 var spaceRegexes = []*regexp.Regexp{
-	regexp.MustCompile(`[^\pL\s_\d]+`), // regex pour supprimer les caractères spéciaux sauf les chiffres
+	regexp.MustCompile(`[^\pL\s\d]+|_+`), // regex pour supprimer les caractères spéciaux et les underscores sauf les chiffres
 }
 
-var extractDateRegex = regexp.MustCompile(`^(.+?)(\d{4}?).*(\d{4}.*)?$`) // Expression régulière pour extraire le nom et l'année du fichier
-
 var tvShowRegex = regexp.MustCompile(`^(.+?)(?:[sS])?(\d{1,})?(?:[eExX])?(\d{2,})(?:.*|$)`) // regex to extract title, season number, and episode number
 
+var episodeRangeRegex = regexp.MustCompile(`(?i)(?:s\d{1,2}e|\d{1,2}x)(\d{2,3})((?:-?e\d{2,3}|-\d{1,2}x\d{2,3}|-\d{2,3}\b)+)`) // regex to extract the following episodes of a multi-episode file (e.g. S01E01E02, S01E01-E03, 1x01-1x02)
+
+var lastNumberRegex = regexp.MustCompile(`\d+$`)
+
+var animeGroupRegex = regexp.MustCompile(`^\s*\[[^\]]+\]`) // regex to detect the leading [Group] of fansub releases
+
+var animeBracketsRegex = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`) // regex to remove the bracketed parts of fansub releases (e.g. [1080p], (x265), [ABCD1234])
+
+var animeEpisodeRegex = regexp.MustCompile(`(?i)^(.+?)\s+-\s+(?:(?:e|ep|episode)\s*)?(\d{1,4})(?:v\d)?(?:\s*-\s*(\d{1,4})(?:v\d)?)?(?:\s.*)?$`) // regex to extract title and absolute episode number(s), e.g. "One Piece - 1071"
+
+var seasonEpisodeRegex = regexp.MustCompile(`(?i)s\d{1,2}e\d{1,3}|\b\d{1,2}x\d{2,3}\b`) // regex to detect explicit season and episode numbers
+
+var airDateRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`^(.+?)[\s._-]+((?:19|20)\d{2})[\s._-](\d{2})[\s._-](\d{2})(?:[\s._-].*)?$`), // regex to extract title and air date of daily shows, e.g. "The.Daily.Show.2023.05.14"
+	regexp.MustCompile(`^(.+?)[\s._-]+(\d{2})[\s._-](\d{2})[\s._-]((?:19|20)\d{2})(?:[\s._-].*)?$`), // regex to extract title and air date of daily shows, e.g. "Quotidien.14.05.2023"
+}
+
+var nonAlphanumericRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+var genericNameRegex = regexp.MustCompile(`(?i)^(?:movie|film|video|feature|main|title[\s\d]*|cd\s*\d*|dis[ck]\s*\d*|\d+)$`) // regex to detect names that don't identify a media
+
+var seasonFolderRegex = regexp.MustCompile(`(?i)^(?:season|saison|series|staffel|s)[\s._-]*(\d{1,2})$`) // regex to extract the season number of a season folder (e.g. "Season 2", "S02")
+
+var episodeOnlyRegex = regexp.MustCompile(`(?i)^(?:e|ep|episode)?[\s._-]*(\d{1,3})(?:[\s._-].*)?$`) // regex to extract the episode number of a filename without title nor season (e.g. "02.mkv", "E02.mkv", "Episode 2 - Title.mkv")
+
+var leadingSeasonEpisodeRegex = regexp.MustCompile(`(?i)^(?:s\d{1,2}e\d{1,3}|\d{1,2}x\d{2,3})`) // regex to detect filenames starting with their season and episode numbers (e.g. "S02E02.mkv")
+
+var specialsFolderRegex = regexp.MustCompile(`(?i)^specials?$`) // regex to detect the folder holding the specials (season 0) of a TV show
+
+var specialNumberRegex = regexp.MustCompile(`(?i)^(.+?)\s+(?:sp|special|ova)\s*(\d{1,3})$`) // regex to extract title and number of a special, e.g. "Show SP01"
+
+var specialNameRegex = regexp.MustCompile(`(?i)^(.+?)\s+-\s+(?:specials?|sp)\s+-\s+(.+)$`) // regex to extract title and name of a special, e.g. "Show - Special - Christmas"
+
+// AnimeMode tells how TV show filenames are checked for absolute episode numbers
+type AnimeMode string
+
+const (
+	AnimeModeAuto   AnimeMode = "auto"   // Only fansub releases, named after their [Group] without season number, are parsed as anime
+	AnimeModeAlways AnimeMode = "always" // Every filename without explicit season number is parsed as anime
+	AnimeModeNever  AnimeMode = "never"  // Filenames are never parsed as anime
+)
+
+// ParseAnimeMode returns the anime mode of the given name (e.g. "always"), failing on an unknown one
+func ParseAnimeMode(name string) (AnimeMode, error) {
+	switch mode := AnimeMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case AnimeModeAuto, AnimeModeAlways, AnimeModeNever:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown anime mode '%s'", name)
+}
+
 var isMn = func(r rune) bool {
 	return unicode.Is(unicode.Mn, r) // Mn: nonspacing marks
 }
@@ -35,30 +86,59 @@ func removeAccents(s string) string {
 	return result
 }
 
-// SanitizeMovieFilename sanitize a movie filename by removing non ASCII characters, removing non-relevant information, returning the name and the year
+// SanitizeMovieFilename sanitize a movie filename by removing non ASCII characters, removing non-relevant information, returning the name and the year.
+// The year is the last plausible year (1900 to next year) preceding the release tags, never the first word of the name,
+// so that titles such as "1917", "2001 A Space Odyssey" or "Blade Runner 2049" are kept whole.
 func SanitizeMovieFilename(filename string) (string, string) {
-	filename = strings.TrimSuffix(filename, filepath.Ext(filename))
-	for _, regex := range spaceRegexes {
-		filename = regex.ReplaceAllString(filename, " ")
-	}
-	filename = removeAccents(filename)
+	return sanitizeReleaseTitle(ParseRelease(filename))
+}
 
-	for _, regex := range deleteRegexes {
-		filename = regex.ReplaceAllString(filename, "")
-	}
+// SanitizeMovieFilenameInContext is SanitizeMovieFilename falling back to the parent folders of the file,
+// from the nearest to the farthest, when the filename lacks the year or is a generic name (e.g. "Inception (2010)/movie.mkv").
+func SanitizeMovieFilenameInContext(filename string, folders []string) (string, string) {
+	name, year := SanitizeMovieFilename(filename)
+	return sanitizeInFolders(name, year, folders)
+}
 
-	matches := extractDateRegex.FindStringSubmatch(filename)
-	if len(matches) < 3 {
-		return filename, ""
+// SanitizeMovieFolderInContext is SanitizeMovieFilenameInContext for a movie stored as a folder (e.g. a disc rip),
+// falling back to its parent folders when its name is generic (e.g. "Inception (2010)/Disc 1/BDMV").
+func SanitizeMovieFolderInContext(folder string, folders []string) (string, string) {
+	name, year := sanitizeReleaseTitle(ParseReleaseFolder(folder))
+	return sanitizeInFolders(name, year, folders)
+}
+
+// sanitizeInFolders returns the given title and year unless they lack the year or the title is generic,
+// in which case the nearest parent folder with a year, or with a title if the given one is generic, is used instead
+func sanitizeInFolders(name, year string, folders []string) (string, string) {
+	if year != "" && !isGenericName(name) {
+		return name, year
+	}
+	for i := len(folders) - 1; i >= 0; i-- {
+		folderName, folderYear := sanitizeReleaseTitle(ParseReleaseFolder(folders[i]))
+		if folderName == "" || isGenericName(folderName) {
+			continue
+		}
+		if folderYear != "" || isGenericName(name) {
+			return folderName, folderYear
+		}
 	}
-	name := strings.TrimSpace(matches[1])
-	year := strings.TrimSpace(matches[2])
 	return name, year
 }
 
-// SanitizeTVShowFilename separates a TV show filename into title, season number, and episode number
-func SanitizeTVShowFilename(filename string) (string, int, int) {
-	filename = strings.TrimSuffix(filename, filepath.Ext(filename))
+func sanitizeReleaseTitle(release Release) (string, string) {
+	return sanitizeTitle(release.Title), release.Year
+}
+
+// isGenericName reports whether a sanitized name says nothing about the media (e.g. "movie", "video", "" or a bare number)
+func isGenericName(name string) bool {
+	return name == "" || genericNameRegex.MatchString(name)
+}
+
+// SanitizeTVShowFilename separates a TV show filename into title, season number, first and last episode numbers.
+// The last episode number is the same as the first one unless the file holds several episodes (e.g. S01E01E02 or S01E01-E03).
+func SanitizeTVShowFilename(filename string) (string, int, int, int) {
+	episodeEnd := parseEpisodeRangeEnd(filename)
+	filename = stripMediaIDs(strings.TrimSuffix(filename, filepath.Ext(filename)))
 	for _, regex := range spaceRegexes {
 		filename = regex.ReplaceAllString(filename, " ")
 	}
@@ -70,7 +150,7 @@ func SanitizeTVShowFilename(filename string) (string, int, int) {
 
 	matches := tvShowRegex.FindStringSubmatch(filename)
 	if len(matches) < 4 {
-		return filename, 0, 0
+		return filename, 0, 0, 0
 	}
 
 	title := strings.TrimSpace(matches[1])
@@ -93,5 +173,158 @@ func SanitizeTVShowFilename(filename string) (string, int, int) {
 		}
 	}
 
-	return title, seasonNumber, episodeNumber
+	if episodeEnd < episodeNumber {
+		episodeEnd = episodeNumber
+	}
+	return title, seasonNumber, episodeNumber, episodeEnd
+}
+
+// SanitizeTVShowFilenameInContext is SanitizeTVShowFilename falling back to the parent folders of the file when the filename lacks the title
+// or the season: the nearest "Season N" folder gives the season and the nearest other folder gives the title
+// (e.g. "Breaking Bad/Season 2/02.mkv" or "Breaking Bad/S02E02.mkv").
+func SanitizeTVShowFilenameInContext(filename string, folders []string) (string, int, int, int) {
+	title, season, episode, episodeEnd := SanitizeTVShowFilename(filename)
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	var showFolder string
+	var folderSeason = -1
+	for i := len(folders) - 1; i >= 0; i-- {
+		if specialsFolderRegex.MatchString(strings.TrimSpace(folders[i])) {
+			if folderSeason < 0 {
+				folderSeason = 0
+			}
+			continue
+		}
+		if matches := seasonFolderRegex.FindStringSubmatch(strings.TrimSpace(folders[i])); matches != nil {
+			if folderSeason < 0 {
+				folderSeason, _ = strconv.Atoi(matches[1])
+			}
+			continue
+		}
+		if folderTitle, _ := sanitizeReleaseTitle(ParseReleaseFolder(folders[i])); !isGenericName(folderTitle) {
+			showFolder = folderTitle
+			break
+		}
+	}
+	if showFolder == "" {
+		return title, season, episode, episodeEnd
+	}
+
+	if leadingSeasonEpisodeRegex.MatchString(name) {
+		release := ParseRelease(filename)
+		episodeEnd = parseEpisodeRangeEnd(filename)
+		if episodeEnd < release.Episode {
+			episodeEnd = release.Episode
+		}
+		return showFolder, release.Season, release.Episode, episodeEnd
+	}
+	if folderSeason >= 0 && !seasonEpisodeRegex.MatchString(name) {
+		if matches := episodeOnlyRegex.FindStringSubmatch(name); matches != nil {
+			episode, _ = strconv.Atoi(matches[1])
+			return showFolder, folderSeason, episode, episode
+		}
+	}
+	return title, season, episode, episodeEnd
+}
+
+// SanitizeSpecialFilename extracts the title of a TV show and the number or the name of one of its specials (season 0),
+// e.g. "Show SP01.mkv" or "Show - Special - Christmas.mkv". Specials named with their season and episode numbers (e.g. "Show.S00E05.mkv")
+// are handled by SanitizeTVShowFilename. It returns false if the filename isn't a special.
+func SanitizeSpecialFilename(filename string) (string, int, string, bool) {
+	if seasonEpisodeRegex.MatchString(filename) {
+		return "", 0, "", false
+	}
+	name := stripMediaIDs(strings.TrimSuffix(filename, filepath.Ext(filename)))
+	name = strings.Join(strings.Fields(strings.NewReplacer("_", " ", ".", " ").Replace(name)), " ")
+	if matches := specialNumberRegex.FindStringSubmatch(name); matches != nil {
+		episode, _ := strconv.Atoi(matches[2])
+		return sanitizeTitle(matches[1]), episode, "", true
+	}
+	if matches := specialNameRegex.FindStringSubmatch(name); matches != nil {
+		return sanitizeTitle(matches[1]), 0, strings.TrimSpace(matches[2]), true
+	}
+	return "", 0, "", false
+}
+
+// sanitizeTitle removes the accents and special characters of a title
+func sanitizeTitle(title string) string {
+	title = removeAccents(title)
+	for _, regex := range spaceRegexes {
+		title = regex.ReplaceAllString(title, " ")
+	}
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// normalizeName turns a name into a lower case key without accents nor punctuation, e.g. "Le Dîner de cons" -> "le-diner-de-cons"
+func normalizeName(name string) string {
+	key := strings.ToLower(removeAccents(name))
+	return strings.Trim(nonAlphanumericRegex.ReplaceAllString(key, "-"), "-")
+}
+
+// SanitizeDailyFilename extracts the title and the air date of a daily show episode (talk shows, news),
+// e.g. "The.Daily.Show.2023.05.14.mkv" or "Quotidien.14.05.2023.mkv". The air date is ISO formatted (2023-05-14).
+// It returns false if the filename doesn't hold a valid air date or if it holds explicit season and episode numbers.
+func SanitizeDailyFilename(filename string) (string, string, bool) {
+	if seasonEpisodeRegex.MatchString(filename) {
+		return "", "", false
+	}
+	name := strings.TrimSpace(stripMediaIDs(strings.TrimSuffix(filename, filepath.Ext(filename))))
+	for i, regex := range airDateRegexes {
+		matches := regex.FindStringSubmatch(name)
+		if matches == nil {
+			continue
+		}
+		var year, month, day = matches[2], matches[3], matches[4]
+		if i == 1 {
+			day, month, year = matches[2], matches[3], matches[4]
+		}
+		airDate := fmt.Sprintf("%s-%s-%s", year, month, day)
+		if _, err := time.Parse("2006-01-02", airDate); err != nil {
+			continue
+		}
+		return sanitizeTitle(matches[1]), airDate, true
+	}
+	return "", "", false
+}
+
+// SanitizeAnimeFilename extracts the title and the absolute episode numbers of a fansub release, e.g. "[Group] One Piece - 1071 [1080p].mkv".
+// The last episode number is the same as the first one unless the file holds several episodes (e.g. "Show - 01-02").
+// It returns false if the filename doesn't hold an absolute episode number, or if it isn't an anime release for the given mode.
+func SanitizeAnimeFilename(filename string, mode AnimeMode) (string, int, int, bool) {
+	if mode == AnimeModeNever || seasonEpisodeRegex.MatchString(filename) {
+		return "", 0, 0, false
+	}
+	if mode != AnimeModeAlways && !animeGroupRegex.MatchString(filename) {
+		return "", 0, 0, false
+	}
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	name = animeBracketsRegex.ReplaceAllString(name, " ")
+	name = strings.Join(strings.Fields(strings.NewReplacer("_", " ", ".", " ").Replace(name)), " ")
+
+	matches := animeEpisodeRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return "", 0, 0, false
+	}
+	episode, _ := strconv.Atoi(matches[2])
+	episodeEnd := episode
+	if matches[3] != "" {
+		episodeEnd, _ = strconv.Atoi(matches[3])
+	}
+	if episodeEnd < episode {
+		episodeEnd = episode
+	}
+	return sanitizeTitle(matches[1]), episode, episodeEnd, true
+}
+
+// parseEpisodeRangeEnd returns the last episode number of a multi-episode filename, 0 if the file holds a single episode
+func parseEpisodeRangeEnd(filename string) int {
+	matches := episodeRangeRegex.FindStringSubmatch(filename)
+	if matches == nil {
+		return 0
+	}
+	episodeEnd, err := strconv.Atoi(lastNumberRegex.FindString(matches[2]))
+	if err != nil {
+		return 0
+	}
+	return episodeEnd
 }
@@ -0,0 +1,208 @@
+package pkg
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the tests")
+
+// TestSanitizeMovieFilename checks the names and years extracted from the real-world filenames of testdata/movie-filenames.txt
+// against testdata/movie-filenames.golden. Run the tests with -update to regenerate the golden file after a deliberate change.
+func TestSanitizeMovieFilename(t *testing.T) {
+	filenames, err := readLines(filepath.Join("testdata", "movie-filenames.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual strings.Builder
+	for _, filename := range filenames {
+		name, year := SanitizeMovieFilename(filename)
+		actual.WriteString(fmt.Sprintf("%s\t%s\t%s\n", filename, name, year))
+	}
+
+	goldenPath := filepath.Join("testdata", "movie-filenames.golden")
+	if *updateGolden {
+		if err = os.WriteFile(goldenPath, []byte(actual.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := readLines(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(actual.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("got %d results, golden file has %d lines", len(lines), len(expected))
+	}
+	for i := range lines {
+		if lines[i] != expected[i] {
+			t.Errorf("SanitizeMovieFilename mismatch\n got: %q\nwant: %q", lines[i], expected[i])
+		}
+	}
+}
+
+func TestParseAnimeMode(t *testing.T) {
+	var tests = []struct {
+		name     string
+		expected AnimeMode
+		ok       bool
+	}{
+		{name: "auto", expected: AnimeModeAuto, ok: true},
+		{name: "Always", expected: AnimeModeAlways, ok: true},
+		{name: " NEVER ", expected: AnimeModeNever, ok: true},
+		{name: "on"},
+		{name: ""},
+	}
+	for _, test := range tests {
+		mode, err := ParseAnimeMode(test.name)
+		if mode != test.expected || (err == nil) != test.ok {
+			t.Errorf("ParseAnimeMode(%q) = %q, %v, want %q, ok %t", test.name, mode, err, test.expected, test.ok)
+		}
+	}
+}
+
+func TestSanitizeAnimeFilename(t *testing.T) {
+	var tests = []struct {
+		filename   string
+		mode       AnimeMode
+		title      string
+		episode    int
+		episodeEnd int
+		ok         bool
+	}{
+		{filename: "[SubsPlease] One Piece - 1071 (1080p) [ABCD1234].mkv", mode: AnimeModeAuto, title: "One Piece", episode: 1071, episodeEnd: 1071, ok: true},
+		{filename: "[Erai-raws] Jujutsu Kaisen - 24v2 [1080p][Multiple Subtitle].mkv", mode: AnimeModeAuto, title: "Jujutsu Kaisen", episode: 24, episodeEnd: 24, ok: true},
+		{filename: "[Group] Shingeki no Kyojin - 01-02 [720p].mkv", mode: AnimeModeAuto, title: "Shingeki no Kyojin", episode: 1, episodeEnd: 2, ok: true},
+		{filename: "[Group]_Naruto_Shippuuden_-_Ep_500_[720p].mkv", mode: AnimeModeAuto, title: "Naruto Shippuuden", episode: 500, episodeEnd: 500, ok: true},
+		{filename: "[Group] Re Zero - 05 - The Morning of Our Promise.mkv", mode: AnimeModeAuto, title: "Re Zero", episode: 5, episodeEnd: 5, ok: true},
+		{filename: "One Piece - 1071.mkv", mode: AnimeModeAuto},
+		{filename: "One Piece - 1071.mkv", mode: AnimeModeAlways, title: "One Piece", episode: 1071, episodeEnd: 1071, ok: true},
+		{filename: "[SubsPlease] One Piece - 1071 (1080p).mkv", mode: AnimeModeNever},
+		{filename: "[Group] Attack on Titan S04E01 [1080p].mkv", mode: AnimeModeAlways},
+		{filename: "[Group] Attack on Titan [1080p].mkv", mode: AnimeModeAuto},
+	}
+	for _, test := range tests {
+		title, episode, episodeEnd, ok := SanitizeAnimeFilename(test.filename, test.mode)
+		if title != test.title || episode != test.episode || episodeEnd != test.episodeEnd || ok != test.ok {
+			t.Errorf("SanitizeAnimeFilename(%q, %s) = %q, %d, %d, %t, want %q, %d, %d, %t", test.filename, test.mode, title, episode, episodeEnd, ok, test.title, test.episode, test.episodeEnd, test.ok)
+		}
+	}
+}
+
+func TestSanitizeDailyFilename(t *testing.T) {
+	var tests = []struct {
+		filename string
+		title    string
+		airDate  string
+		ok       bool
+	}{
+		{filename: "The.Daily.Show.2023.05.14.mkv", title: "The Daily Show", airDate: "2023-05-14", ok: true},
+		{filename: "Quotidien.14.05.2023.720p.WEB.mkv", title: "Quotidien", airDate: "2023-05-14", ok: true},
+		{filename: "The Tonight Show - 2023-05-14 - Guest.mkv", title: "The Tonight Show", airDate: "2023-05-14", ok: true},
+		{filename: "Le_Journal_de_20h_2023_01_31.mp4", title: "Le Journal de 20h", airDate: "2023-01-31", ok: true},
+		{filename: "The.Daily.Show.2023.02.30.mkv"},
+		{filename: "The.Daily.Show.S28E60.2023.05.14.mkv"},
+		{filename: "Blade.Runner.2049.2017.1080p.mkv"},
+		{filename: "2023.05.14.mkv"},
+	}
+	for _, test := range tests {
+		title, airDate, ok := SanitizeDailyFilename(test.filename)
+		if title != test.title || airDate != test.airDate || ok != test.ok {
+			t.Errorf("SanitizeDailyFilename(%q) = %q, %q, %t, want %q, %q, %t", test.filename, title, airDate, ok, test.title, test.airDate, test.ok)
+		}
+	}
+}
+
+func TestSanitizeSpecialFilename(t *testing.T) {
+	var tests = []struct {
+		filename string
+		title    string
+		episode  int
+		name     string
+		ok       bool
+	}{
+		{filename: "Doctor.Who.SP01.mkv", title: "Doctor Who", episode: 1, ok: true},
+		{filename: "Made in Abyss OVA 2.mkv", title: "Made in Abyss", episode: 2, ok: true},
+		{filename: "Sherlock Special 1.mkv", title: "Sherlock", episode: 1, ok: true},
+		{filename: "Doctor Who - Special - The Christmas Invasion.mkv", title: "Doctor Who", name: "The Christmas Invasion", ok: true},
+		{filename: "Doctor_Who_-_SP_-_Christmas.mkv", title: "Doctor Who", name: "Christmas", ok: true},
+		{filename: "Doctor.Who.S00E05.mkv"},
+		{filename: "Doctor.Who.S01E01.mkv"},
+		{filename: "Spider-Man.Into.the.Spider-Verse.mkv"},
+	}
+	for _, test := range tests {
+		title, episode, name, ok := SanitizeSpecialFilename(test.filename)
+		if title != test.title || episode != test.episode || name != test.name || ok != test.ok {
+			t.Errorf("SanitizeSpecialFilename(%q) = %q, %d, %q, %t, want %q, %d, %q, %t", test.filename, title, episode, name, ok, test.title, test.episode, test.name, test.ok)
+		}
+	}
+}
+
+func TestSanitizeMovieFilenameInContext(t *testing.T) {
+	var tests = []struct {
+		filename string
+		folders  []string
+		name     string
+		year     string
+	}{
+		{filename: "movie.mkv", folders: []string{"Inception (2010)"}, name: "Inception", year: "2010"},
+		{filename: "Inception.1080p.mkv", folders: []string{"Movies", "Inception (2010)"}, name: "Inception", year: "2010"},
+		{filename: "Inception.2010.1080p.mkv", folders: []string{"Heat (1995)"}, name: "Inception", year: "2010"},
+		{filename: "CD1.avi", folders: []string{"Heat.1995.DVDRip", "CD1"}, name: "Heat", year: "1995"},
+		{filename: "Inception.mkv", folders: []string{"Movies"}, name: "Inception", year: ""},
+		{filename: "movie.mkv", folders: nil, name: "movie", year: ""},
+	}
+	for _, test := range tests {
+		name, year := SanitizeMovieFilenameInContext(test.filename, test.folders)
+		if name != test.name || year != test.year {
+			t.Errorf("SanitizeMovieFilenameInContext(%q, %q) = %q, %q, want %q, %q", test.filename, test.folders, name, year, test.name, test.year)
+		}
+	}
+}
+
+func TestSanitizeMovieFolderInContext(t *testing.T) {
+	var tests = []struct {
+		folder  string
+		folders []string
+		name    string
+		year    string
+	}{
+		{folder: "Inception.2010.1080p.BluRay", folders: nil, name: "Inception", year: "2010"},
+		{folder: "Disc 1", folders: []string{"Inception (2010)"}, name: "Inception", year: "2010"},
+		{folder: "Inception", folders: []string{"Movies", "Inception (2010)"}, name: "Inception", year: "2010"},
+	}
+	for _, test := range tests {
+		name, year := SanitizeMovieFolderInContext(test.folder, test.folders)
+		if name != test.name || year != test.year {
+			t.Errorf("SanitizeMovieFolderInContext(%q, %q) = %q, %q, want %q, %q", test.folder, test.folders, name, year, test.name, test.year)
+		}
+	}
+}
+
+func TestSanitizeTVShowFilenameInContext(t *testing.T) {
+	var tests = []struct {
+		filename   string
+		folders    []string
+		title      string
+		season     int
+		episode    int
+		episodeEnd int
+	}{
+		{filename: "Breaking.Bad.S02E03.mkv", folders: []string{"Breaking Bad", "Season 2"}, title: "Breaking Bad", season: 2, episode: 3, episodeEnd: 3},
+		{filename: "03.mkv", folders: []string{"Breaking Bad", "Season 2"}, title: "Breaking Bad", season: 2, episode: 3, episodeEnd: 3},
+		{filename: "Episode 3 - Bit by a Dead Bee.mkv", folders: []string{"Breaking Bad (2008)", "S02"}, title: "Breaking Bad", season: 2, episode: 3, episodeEnd: 3},
+		{filename: "S02E03E04.mkv", folders: []string{"Breaking Bad"}, title: "Breaking Bad", season: 2, episode: 3, episodeEnd: 4},
+		{filename: "E05.mkv", folders: []string{"Doctor Who", "Specials"}, title: "Doctor Who", season: 0, episode: 5, episodeEnd: 5},
+		{filename: "Breaking.Bad.S02E03.mkv", folders: nil, title: "Breaking Bad", season: 2, episode: 3, episodeEnd: 3},
+	}
+	for _, test := range tests {
+		title, season, episode, episodeEnd := SanitizeTVShowFilenameInContext(test.filename, test.folders)
+		if title != test.title || season != test.season || episode != test.episode || episodeEnd != test.episodeEnd {
+			t.Errorf("SanitizeTVShowFilenameInContext(%q, %q) = %q, %d, %d, %d, want %q, %d, %d, %d", test.filename, test.folders, title, season, episode, episodeEnd, test.title, test.season, test.episode, test.episodeEnd)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var partialExtensions = []string{ // extensions of the files still being downloaded
+	".part",
+	".partial",
+	".!qb",
+	".crdownload",
+}
+
+// StabilityTracker remembers the size and modification time of the media files seen by the scans of a source folder,
+// so that a file still being written is only accepted once it has been left unchanged for the settle period.
+// A nil tracker accepts every file.
+type StabilityTracker struct {
+	settlePeriod time.Duration
+	mutex        sync.Mutex
+	files        map[string]fileState // States of the files not accepted yet, by path
+	now          func() time.Time     // Current time, replaced in tests
+}
+
+type fileState struct {
+	size      int64
+	modTime   time.Time
+	firstSeen time.Time // First scan the file was seen with this size and modification time
+	lastSeen  time.Time // Last scan the file was seen
+}
+
+// NewStabilityTracker returns a tracker accepting files left unchanged for the settle period, 0 accepting every file
+func NewStabilityTracker(settlePeriod time.Duration) *StabilityTracker {
+	return &StabilityTracker{
+		settlePeriod: settlePeriod,
+		files:        make(map[string]fileState),
+		now:          time.Now,
+	}
+}
+
+// IsStable records the current size and modification time of a file and tells whether they have been unchanged for the settle period.
+// A file seen for the first time is accepted if it was last modified before the settle period,
+// otherwise its size and modification time must stay the same across scans until the settle period is over.
+func (s *StabilityTracker) IsStable(filePath string, info os.FileInfo) bool {
+	if s == nil || s.settlePeriod <= 0 {
+		return true
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	state, found := s.files[filePath]
+	if !found && now.Sub(info.ModTime()) >= s.settlePeriod {
+		return true
+	}
+	if !found || state.size != info.Size() || !state.modTime.Equal(info.ModTime()) {
+		state = fileState{size: info.Size(), modTime: info.ModTime(), firstSeen: now}
+	}
+	if now.Sub(state.firstSeen) >= s.settlePeriod && now.Sub(info.ModTime()) >= s.settlePeriod {
+		delete(s.files, filePath)
+		return true
+	}
+	state.lastSeen = now
+	s.files[filePath] = state
+	return false
+}
+
+// Sweep forgets the files of a folder which were not seen since the given time, e.g. removed before they were accepted
+func (s *StabilityTracker) Sweep(folder string, since time.Time) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for filePath, state := range s.files {
+		if isInFolder(filePath, folder) && state.lastSeen.Before(since) {
+			delete(s.files, filePath)
+		}
+	}
+}
+
+// isPartialDownload tells whether a file is still being downloaded by its extension, e.g. movie.mkv.part
+func isPartialDownload(filename string) bool {
+	for _, extension := range partialExtensions {
+		if strings.EqualFold(filepath.Ext(filename), extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPartialDownload tells whether a file of the folder is the partial download of the given file,
+// some download clients writing the final file next to its partial download
+func hasPartialDownload(entries []os.DirEntry, filename string) bool {
+	for _, entry := range entries {
+		if isPartialDownload(entry.Name()) && strings.EqualFold(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())), filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInFolder tells whether a path is inside the given folder, at any depth
+func isInFolder(filePath, folder string) bool {
+	relativePath, err := filepath.Rel(folder, filePath)
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+// isStable tells whether a media file is done being written, logging the reason if not
+func (o TreeOptions) isStable(folder string, entries []os.DirEntry, entry os.DirEntry) bool {
+	if hasPartialDownload(entries, entry.Name()) {
+		log.Println("Skipping file still being downloaded: ", entry.Name())
+		return false
+	}
+	info, err := entry.Info()
+	if err != nil {
+		log.Printf("Failed to read the size of %s: %v", entry.Name(), err)
+		return false
+	}
+	if !o.Stability.IsStable(filepath.Join(folder, entry.Name()), info) {
+		log.Println("Skipping file still being written: ", entry.Name())
+		return false
+	}
+	return true
+}
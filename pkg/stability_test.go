@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testFileInfo is the size and modification time of a file, as returned by os.Stat
+type testFileInfo struct {
+	os.FileInfo
+	size    int64
+	modTime time.Time
+}
+
+func (i testFileInfo) Size() int64        { return i.size }
+func (i testFileInfo) ModTime() time.Time { return i.modTime }
+
+// testClock is a clock moved forward by the tests
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func newTestStabilityTracker(settlePeriod time.Duration) (*StabilityTracker, *testClock) {
+	clock := &testClock{now: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)}
+	tracker := NewStabilityTracker(settlePeriod)
+	tracker.now = clock.Now
+	return tracker, clock
+}
+
+func TestStabilityTrackerIsStable(t *testing.T) {
+	const settlePeriod = time.Minute
+	type scan struct {
+		after    time.Duration // Time elapsed since the previous scan
+		size     int64
+		modified time.Duration // Age of the file at the time of the scan
+		expected bool
+	}
+	var tests = []struct {
+		name  string
+		scans []scan
+	}{
+		{name: "first sight of an old file", scans: []scan{
+			{size: 100, modified: time.Hour, expected: true},
+		}},
+		{name: "first sight of a recent file", scans: []scan{
+			{size: 100, modified: 10 * time.Second},
+		}},
+		{name: "settled file", scans: []scan{
+			{size: 100, modified: 10 * time.Second},
+			{after: 30 * time.Second, size: 100, modified: 40 * time.Second},
+			{after: 30 * time.Second, size: 100, modified: 70 * time.Second, expected: true},
+		}},
+		{name: "changed size", scans: []scan{
+			{size: 100, modified: 10 * time.Second},
+			{after: 55 * time.Second, size: 200, modified: 65 * time.Second},
+			{after: 30 * time.Second, size: 200, modified: 95 * time.Second},
+			{after: 30 * time.Second, size: 200, modified: 125 * time.Second, expected: true},
+		}},
+		{name: "changed modification time", scans: []scan{
+			{size: 100, modified: 10 * time.Second},
+			{after: 55 * time.Second, size: 100, modified: 5 * time.Second},
+			{after: 10 * time.Second, size: 100, modified: 15 * time.Second},
+		}},
+	}
+	for _, test := range tests {
+		tracker, clock := newTestStabilityTracker(settlePeriod)
+		for i, scan := range test.scans {
+			clock.now = clock.now.Add(scan.after)
+			info := testFileInfo{size: scan.size, modTime: clock.now.Add(-scan.modified)}
+			if result := tracker.IsStable("/source/movie.mkv", info); result != scan.expected {
+				t.Errorf("%s: IsStable on scan %d = %t, want %t", test.name, i+1, result, scan.expected)
+			}
+		}
+	}
+}
+
+func TestStabilityTrackerDisabled(t *testing.T) {
+	var info = testFileInfo{size: 100, modTime: time.Now()}
+	var nilTracker *StabilityTracker
+	if !nilTracker.IsStable("/source/movie.mkv", info) {
+		t.Error("nil tracker: IsStable = false, want true")
+	}
+	if !NewStabilityTracker(0).IsStable("/source/movie.mkv", info) {
+		t.Error("tracker without settle period: IsStable = false, want true")
+	}
+}
+
+func TestStabilityTrackerSweep(t *testing.T) {
+	tracker, clock := newTestStabilityTracker(time.Minute)
+	var removed = filepath.Join("/source", "Movies", "removed.mkv")
+	var seen = filepath.Join("/source", "Movies", "seen.mkv")
+	var other = filepath.Join("/source", "Shows", "episode.mkv")
+	for _, filePath := range []string{removed, seen, other} {
+		tracker.IsStable(filePath, testFileInfo{size: 100, modTime: clock.now})
+	}
+	clock.now = clock.now.Add(30 * time.Second)
+	start := clock.now
+	tracker.IsStable(seen, testFileInfo{size: 100, modTime: start.Add(-30 * time.Second)})
+	tracker.Sweep(filepath.Join("/source", "Movies"), start)
+
+	for filePath, expected := range map[string]bool{removed: false, seen: true, other: true} {
+		if _, found := tracker.files[filePath]; found != expected {
+			t.Errorf("Sweep: %s tracked = %t, want %t", filePath, found, expected)
+		}
+	}
+
+	// A swept file starts over, even if it was seen long ago
+	clock.now = clock.now.Add(45 * time.Second)
+	if tracker.IsStable(removed, testFileInfo{size: 100, modTime: clock.now.Add(-10 * time.Second)}) {
+		t.Error("IsStable on a swept file = true, want false")
+	}
+}
@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/asticode/go-astisub"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var subtitleExtensions = []string{
+	".srt",
+	".ass",
+	".ssa",
+	".vtt",
+}
+
+var subtitleLanguageRegex = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]{2})?$`) // regex to match language codes, e.g. fr, fre, pt-br
+
+var subtitleLanguages = map[string]string{ // language names used instead of codes in subtitle filenames
+	"english":    "en",
+	"french":     "fr",
+	"francais":   "fr",
+	"german":     "de",
+	"spanish":    "es",
+	"italian":    "it",
+	"japanese":   "ja",
+	"portuguese": "pt",
+}
+
+// SubtitleFile is a sidecar subtitle matched to a video file by basename, e.g. Movie.fr.srt or Movie.en.forced.srt
+type SubtitleFile struct {
+	Path     string
+	Filename string
+	Language string // Language code of the subtitle (e.g. fr, en), Unknown if the filename has none
+	Forced   bool   // Subtitle only covering foreign language parts
+	SDH      bool   // Subtitle for the deaf and hard of hearing
+}
+
+// ParseSubtitleFilename matches a subtitle file to a video file and extracts its language and flags.
+// It returns false if the subtitle does not belong to the video, including when the subtitle name holds a tag which is neither
+// a language nor a flag, as it then belongs to another video (e.g. Movie.Extended.en.srt is not a subtitle of Movie.mkv).
+func ParseSubtitleFilename(videoFilename, subtitleFilename string) (SubtitleFile, bool) {
+	if !hasSubtitleExtension(subtitleFilename) {
+		return SubtitleFile{}, false
+	}
+	videoName := strings.ToLower(strings.TrimSuffix(videoFilename, filepath.Ext(videoFilename)))
+	subtitleName := strings.ToLower(strings.TrimSuffix(subtitleFilename, filepath.Ext(subtitleFilename)))
+	if subtitleName != videoName && !strings.HasPrefix(subtitleName, videoName+".") {
+		return SubtitleFile{}, false
+	}
+
+	var subtitle = SubtitleFile{Filename: subtitleFilename, Language: "Unknown"}
+	for _, tag := range strings.Split(strings.TrimPrefix(subtitleName, videoName), ".") {
+		if tag == "" {
+			continue
+		}
+		switch tag {
+		case "forced":
+			subtitle.Forced = true
+		case "sdh", "cc", "hi":
+			subtitle.SDH = true
+		case "default":
+		default:
+			if language, ok := subtitleLanguages[tag]; ok {
+				subtitle.Language = language
+			} else if subtitleLanguageRegex.MatchString(tag) {
+				subtitle.Language = tag
+			} else {
+				return SubtitleFile{}, false
+			}
+		}
+	}
+	return subtitle, true
+}
+
+// findSubtitles returns the sidecar subtitles of a video file among the entries of its folder.
+// A subtitle matching several of the videos of the folder belongs to the one with the longest name,
+// e.g. Movie.Director's.Cut.fr.srt goes with Movie.Director's.Cut.mkv rather than with Movie.mkv.
+func findSubtitles(folder string, entries []os.DirEntry, videoFilename string, videoFilenames []string) []SubtitleFile {
+	var subtitles = make([]SubtitleFile, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		subtitle, ok := ParseSubtitleFilename(videoFilename, entry.Name())
+		if !ok || hasLongerVideoMatch(videoFilename, videoFilenames, entry.Name()) {
+			continue
+		}
+		subtitle.Path = folder
+		subtitles = append(subtitles, subtitle)
+	}
+	return subtitles
+}
+
+// hasLongerVideoMatch reports whether another video of the folder, with a longer name, matches the subtitle
+func hasLongerVideoMatch(videoFilename string, videoFilenames []string, subtitleFilename string) bool {
+	var length = len(strings.TrimSuffix(videoFilename, filepath.Ext(videoFilename)))
+	for _, other := range videoFilenames {
+		if len(strings.TrimSuffix(other, filepath.Ext(other))) <= length {
+			continue
+		}
+		if _, ok := ParseSubtitleFilename(other, subtitleFilename); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSubtitleExtension(filename string) bool {
+	for _, extension := range subtitleExtensions {
+		if strings.EqualFold(filepath.Ext(filename), extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertSubtitle converts a subtitle file to WebVTT, shifting its timecodes by the given duration
+// so that it stays in sync with the video once the intro is prepended
+func ConvertSubtitle(source, destination string, shift time.Duration) error {
+	subtitles, err := astisub.OpenFile(source)
+	if err != nil {
+		return fmt.Errorf("failed to open subtitle file %s: %w", source, err)
+	}
+	subtitles.Add(shift)
+	file, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err = subtitles.WriteToWebVTT(file); err != nil {
+		return fmt.Errorf("failed to write subtitle file %s: %w", destination, err)
+	}
+	return nil
+}
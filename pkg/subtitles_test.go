@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSubtitleFilename(t *testing.T) {
+	var tests = []struct {
+		video    string
+		subtitle string
+		expected SubtitleFile
+		ok       bool
+	}{
+		{video: "Movie.mkv", subtitle: "Movie.srt", expected: SubtitleFile{Filename: "Movie.srt", Language: "Unknown"}, ok: true},
+		{video: "Movie.mkv", subtitle: "Movie.fr.srt", expected: SubtitleFile{Filename: "Movie.fr.srt", Language: "fr"}, ok: true},
+		{video: "Movie.mkv", subtitle: "movie.EN.forced.ass", expected: SubtitleFile{Filename: "movie.EN.forced.ass", Language: "en", Forced: true}, ok: true},
+		{video: "Movie.mkv", subtitle: "Movie.English.SDH.srt", expected: SubtitleFile{Filename: "Movie.English.SDH.srt", Language: "en", SDH: true}, ok: true},
+		{video: "Movie.mkv", subtitle: "Movie.pt-br.default.vtt", expected: SubtitleFile{Filename: "Movie.pt-br.default.vtt", Language: "pt-br"}, ok: true},
+		{video: "Movie.2010.1080p.mkv", subtitle: "Movie.2010.1080p.fre.cc.srt", expected: SubtitleFile{Filename: "Movie.2010.1080p.fre.cc.srt", Language: "fre", SDH: true}, ok: true},
+		{video: "Movie.mkv", subtitle: "Movie.Extended.en.srt"},
+		{video: "Movie.mkv", subtitle: "Movie.Directors.Cut.srt"},
+		{video: "Movie.Extended.mkv", subtitle: "Movie.Extended.en.srt", expected: SubtitleFile{Filename: "Movie.Extended.en.srt", Language: "en"}, ok: true},
+		{video: "Movie.mkv", subtitle: "Movie2.fr.srt"},
+		{video: "Movie.mkv", subtitle: "Movie.fr.txt"},
+		{video: "Movie.mkv", subtitle: "Other.fr.srt"},
+	}
+	for _, test := range tests {
+		subtitle, ok := ParseSubtitleFilename(test.video, test.subtitle)
+		if ok != test.ok || !reflect.DeepEqual(subtitle, test.expected) {
+			t.Errorf("ParseSubtitleFilename(%q, %q) = %+v, %t, want %+v, %t", test.video, test.subtitle, subtitle, ok, test.expected, test.ok)
+		}
+	}
+}
+
+// TestFindSubtitles checks that a subtitle matching several videos goes with the video with the longest name
+func TestFindSubtitles(t *testing.T) {
+	var folder = t.TempDir()
+	var filenames = []string{
+		"Movie.mkv",
+		"Movie.fr.srt",
+		"Movie.en.mkv",
+		"Movie.en.srt",
+		"Movie.Extended.mkv",
+		"Movie.Extended.en.srt",
+	}
+	for _, filename := range filenames {
+		if err := os.WriteFile(filepath.Join(folder, filename), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var videos = []string{"Movie.mkv", "Movie.en.mkv", "Movie.Extended.mkv"}
+	var expected = map[string][]string{
+		"Movie.mkv":          {"Movie.fr.srt"},
+		"Movie.en.mkv":       {"Movie.en.srt"},
+		"Movie.Extended.mkv": {"Movie.Extended.en.srt"},
+	}
+	for _, video := range videos {
+		var found = make([]string, 0)
+		for _, subtitle := range findSubtitles(folder, entries, video, videos) {
+			found = append(found, subtitle.Filename)
+		}
+		if !reflect.DeepEqual(found, expected[video]) {
+			t.Errorf("subtitles of %s: got %v, want %v", video, found, expected[video])
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"encoding/json"
+	"github.com/go-redis/redis"
+	"github.com/patrickmn/go-cache"
+	"log"
+	"time"
+)
+
+// Retention of the TMDB API responses, TV shows being kept a shorter time as their seasons change while they air
+const (
+	movieInfoExpiration = 30 * 24 * time.Hour
+	tvInfoExpiration    = 7 * 24 * time.Hour
+	findExpiration      = 7 * 24 * time.Hour
+)
+
+// detailsCache stores the responses of the TMDB API requests the bingemate client does not cache, encoded in JSON
+type detailsCache interface {
+	get(key string, value any) bool
+	set(key string, value any, expiration time.Duration)
+}
+
+type memoryDetailsCache struct {
+	cache *cache.Cache
+}
+
+type redisDetailsCache struct {
+	client *redis.Client
+}
+
+func newMemoryDetailsCache() detailsCache {
+	return &memoryDetailsCache{
+		cache: cache.New(cache.NoExpiration, 10*time.Minute),
+	}
+}
+
+func newRedisDetailsCache(redisHost, redisPassword string) detailsCache {
+	return &redisDetailsCache{
+		client: redis.NewClient(&redis.Options{
+			Addr:     redisHost,
+			Password: redisPassword,
+			DB:       0,
+		}),
+	}
+}
+
+func (c *memoryDetailsCache) get(key string, value any) bool {
+	data, ok := c.cache.Get(key)
+	return ok && decodeCached(key, data.([]byte), value)
+}
+
+func (c *memoryDetailsCache) set(key string, value any, expiration time.Duration) {
+	if data, ok := encodeCached(key, value); ok {
+		c.cache.Set(key, data, expiration)
+	}
+}
+
+func (c *redisDetailsCache) get(key string, value any) bool {
+	data, err := c.client.Get(key).Bytes()
+	return err == nil && decodeCached(key, data, value)
+}
+
+func (c *redisDetailsCache) set(key string, value any, expiration time.Duration) {
+	if data, ok := encodeCached(key, value); ok {
+		if err := c.client.Set(key, data, expiration).Err(); err != nil {
+			log.Printf("Failed to cache %s: %v", key, err)
+		}
+	}
+}
+
+func encodeCached(key string, value any) ([]byte, bool) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to encode %s: %v", key, err)
+		return nil, false
+	}
+	return data, true
+}
+
+func decodeCached(key string, data []byte, value any) bool {
+	if err := json.Unmarshal(data, value); err != nil {
+		log.Printf("Failed to decode cached %s: %v", key, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package pkg
+
+import (
+	tmdbapi "github.com/ryanbradynd05/go-tmdb"
+	"testing"
+)
+
+// TestTMDBSourceCache checks that cached details are served without reaching TMDB, the source having no API client
+func TestTMDBSourceCache(t *testing.T) {
+	var cache = newMemoryDetailsCache()
+	var source = &tmdbSource{cache: cache}
+	cache.set("indexer_movie_info:603", &tmdbapi.Movie{ID: 603, Title: "Matrix"}, movieInfoExpiration)
+	cache.set("indexer_tv_info:1396", &tmdbapi.TV{ID: 1396, Name: "Breaking Bad"}, tvInfoExpiration)
+
+	movie, err := source.GetMovieInfo(603)
+	if err != nil || movie.Title != "Matrix" {
+		t.Errorf("got movie %+v and error %v", movie, err)
+	}
+	tvShow, err := source.GetTvInfo(1396)
+	if err != nil || tvShow.Name != "Breaking Bad" {
+		t.Errorf("got TV show %+v and error %v", tvShow, err)
+	}
+}
@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	tmdbBaseURL              = "https://api.themoviedb.org/3"
+	absoluteEpisodeGroupType = 2 // Type of the TMDB episode groups numbering the episodes of a show in absolute order
+)
+
+var tmdbHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// tvEpisodeGroup is an alternative ordering of the episodes of a TV show on TMDB, e.g. the absolute order of an anime
+// whose cours are split or merged differently in its TMDB seasons
+type tvEpisodeGroup struct {
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	Type         int                 `json:"type"`
+	EpisodeCount int                 `json:"episode_count"`
+	Groups       []tvEpisodeSubgroup `json:"groups,omitempty"` // Parts of the ordering, only listed by GetEpisodeGroup
+}
+
+type tvEpisodeSubgroup struct {
+	Name     string                `json:"name"`
+	Order    int                   `json:"order"`
+	Episodes []tvEpisodeGroupEntry `json:"episodes"`
+}
+
+type tvEpisodeGroupEntry struct {
+	ID            int `json:"id"`
+	SeasonNumber  int `json:"season_number"`
+	EpisodeNumber int `json:"episode_number"`
+	Order         int `json:"order"`
+}
+
+// mapAbsoluteEpisodeInGroup converts an absolute episode number to a season and episode number following the order of an episode group.
+// It returns false if the number is beyond the episodes of the group.
+func mapAbsoluteEpisodeInGroup(group *tvEpisodeGroup, absoluteEpisode int) (int, int, bool) {
+	var subgroups = make([]tvEpisodeSubgroup, len(group.Groups))
+	copy(subgroups, group.Groups)
+	sort.SliceStable(subgroups, func(i, j int) bool {
+		return subgroups[i].Order < subgroups[j].Order
+	})
+	var remaining = absoluteEpisode
+	for _, subgroup := range subgroups {
+		if remaining <= 0 {
+			break
+		}
+		if remaining > len(subgroup.Episodes) {
+			remaining -= len(subgroup.Episodes)
+			continue
+		}
+		var episodes = make([]tvEpisodeGroupEntry, len(subgroup.Episodes))
+		copy(episodes, subgroup.Episodes)
+		sort.SliceStable(episodes, func(i, j int) bool {
+			return episodes[i].Order < episodes[j].Order
+		})
+		episode := episodes[remaining-1]
+		return episode.SeasonNumber, episode.EpisodeNumber, true
+	}
+	return 0, 0, false
+}
+
+// findAbsoluteEpisodeGroup returns the absolute episode group of a TV show with the most episodes, nil if it has none
+func (m *mediaClient) findAbsoluteEpisodeGroup(tvShowID int) (*tvEpisodeGroup, error) {
+	groups, err := m.client.GetTVEpisodeGroups(tvShowID)
+	if err != nil {
+		return nil, providerError(err)
+	}
+	var absolute *tvEpisodeGroup
+	for _, group := range groups {
+		if group.Type == absoluteEpisodeGroupType && (absolute == nil || group.EpisodeCount > absolute.EpisodeCount) {
+			absolute = group
+		}
+	}
+	if absolute == nil {
+		return nil, nil
+	}
+	group, err := m.client.GetEpisodeGroup(absolute.ID)
+	if err != nil {
+		return nil, providerError(err)
+	}
+	return group, nil
+}
+
+func (t *tmdbSource) GetTVEpisodeGroups(tvID int) ([]*tvEpisodeGroup, error) {
+	var key = fmt.Sprintf("indexer_tv_episode_groups:%d", tvID)
+	var results struct {
+		Results []*tvEpisodeGroup `json:"results"`
+	}
+	if t.cache.get(key, &results) {
+		return results.Results, nil
+	}
+	err := t.throttle.do(func() error {
+		return t.get(fmt.Sprintf("/tv/%d/episode_groups", tvID), &results)
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.cache.set(key, results, tvInfoExpiration)
+	return results.Results, nil
+}
+
+func (t *tmdbSource) GetEpisodeGroup(groupID string) (*tvEpisodeGroup, error) {
+	var key = "indexer_episode_group:" + groupID
+	var group *tvEpisodeGroup
+	if t.cache.get(key, &group) {
+		return group, nil
+	}
+	err := t.throttle.do(func() error {
+		return t.get("/tv/episode_group/"+groupID, &group)
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.cache.set(key, group, tvInfoExpiration)
+	return group, nil
+}
+
+// get decodes the answer of a TMDB API endpoint the TMDB client does not expose.
+// Failures are reported the way the TMDB client does, e.g. "Code (34): The resource you requested could not be found."
+func (t *tmdbSource) get(path string, value any) error {
+	response, err := tmdbHTTPClient.Get(fmt.Sprintf("%s%s?api_key=%s&language=fr", tmdbBaseURL, path, t.apiKey))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		var status struct {
+			Code    int    `json:"status_code"`
+			Message string `json:"status_message"`
+		}
+		if err = json.Unmarshal(body, &status); err != nil || status.Code == 0 {
+			return &httpStatusError{code: response.StatusCode, status: response.Status}
+		}
+		return fmt.Errorf("Code (%d): %s", status.Code, status.Message)
+	}
+	return json.Unmarshal(body, value)
+}
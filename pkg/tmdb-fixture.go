@@ -0,0 +1,340 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bingemate/media-go-pkg/tmdb"
+	tmdbapi "github.com/ryanbradynd05/go-tmdb"
+	"gopkg.in/yaml.v3"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+var fixtureExtensions = []string{".json", ".yaml", ".yml"}
+
+// fixtureSource serves TMDB lookups from a local fixture folder.
+// The folder is laid out as follows:
+//
+//	search/movie/<query>[_<year>].json
+//	search/tv/<query>.json
+//	movie/<id>.json
+//	tv/<id>.json
+//	tv/<id>/s<season>.json
+//	tv/<id>/s<season>e<episode>.json
+//	find/<source>/<external id>.json
+//	tv/<id>/episode_groups.json
+//	episode_group/<group id>.json
+//
+// Every fixture can be written either in JSON or in YAML. Movie and TV show fixtures follow the TMDB API details format,
+// the other ones use the JSON field names of the tmdb package.
+type fixtureSource struct {
+	folder string
+}
+
+// recordingSource forwards lookups to a live source and writes every response to the fixture folder.
+type recordingSource struct {
+	source metadataSource
+	folder string
+}
+
+// NewFixtureMediaClient returns a MediaClient that never reaches TMDB and serves every lookup from the fixture folder.
+func NewFixtureMediaClient(fixtureFolder string) MediaClient {
+	return &mediaClient{
+		client: &fixtureSource{folder: fixtureFolder},
+	}
+}
+
+// NewRecordingMediaClient returns a MediaClient that queries TMDB through the given throttle and records every response in the fixture folder.
+func NewRecordingMediaClient(apiKey, fixtureFolder string, throttle *Throttle) MediaClient {
+	return &mediaClient{
+		client: &recordingSource{
+			source: newTMDBSource(tmdb.NewMediaClient(apiKey), newMemoryDetailsCache(), apiKey, throttle),
+			folder: fixtureFolder,
+		},
+	}
+}
+
+func (f *fixtureSource) SearchMoviesYear(query string, year string, _ int) (*tmdb.PaginatedMovieResults, error) {
+	var results tmdb.PaginatedMovieResults
+	found, err := readFixture(f.folder, movieSearchFixture(query, year), &results)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &tmdb.PaginatedMovieResults{}, nil
+	}
+	return &results, nil
+}
+
+func (f *fixtureSource) GetMovieInfo(id int) (*tmdbapi.Movie, error) {
+	var movie tmdbapi.Movie
+	found, err := readFixture(f.folder, movieFixture(id), &movie)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: no fixture for movie %d", ErrNotFound, id)
+	}
+	return &movie, nil
+}
+
+func (f *fixtureSource) SearchTVShows(query string, _ int, _ bool) (*tmdb.PaginatedTVShowResults, error) {
+	var results tmdb.PaginatedTVShowResults
+	found, err := readFixture(f.folder, tvSearchFixture(query), &results)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &tmdb.PaginatedTVShowResults{}, nil
+	}
+	return &results, nil
+}
+
+func (f *fixtureSource) GetTVEpisode(tvID, season, episodeNumber int) (*tmdb.TVEpisode, error) {
+	var episode tmdb.TVEpisode
+	found, err := readFixture(f.folder, episodeFixture(tvID, season, episodeNumber), &episode)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: no fixture for tv show %d S%02dE%02d", ErrNotFound, tvID, season, episodeNumber)
+	}
+	return &episode, nil
+}
+
+func (f *fixtureSource) GetTvInfo(id int) (*tmdbapi.TV, error) {
+	var tvShow tmdbapi.TV
+	found, err := readFixture(f.folder, tvFixture(id), &tvShow)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: no fixture for tv show %d", ErrNotFound, id)
+	}
+	return &tvShow, nil
+}
+
+func (f *fixtureSource) GetTVSeasonEpisodes(tvID int, season int) ([]*tmdb.TVEpisode, error) {
+	var episodes []*tmdb.TVEpisode
+	found, err := readFixture(f.folder, seasonFixture(tvID, season), &episodes)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: no fixture for tv show %d season %d", ErrNotFound, tvID, season)
+	}
+	return episodes, nil
+}
+
+func (f *fixtureSource) FindByExternalID(id string, source string) (*tmdbapi.FindResults, error) {
+	var results tmdbapi.FindResults
+	_, err := readFixture(f.folder, findFixture(id, source), &results)
+	if err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+func (f *fixtureSource) GetTVEpisodeGroups(tvID int) ([]*tvEpisodeGroup, error) {
+	var groups []*tvEpisodeGroup
+	// Most shows have no episode group, so a missing fixture lists none
+	_, err := readFixture(f.folder, episodeGroupsFixture(tvID), &groups)
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (f *fixtureSource) GetEpisodeGroup(groupID string) (*tvEpisodeGroup, error) {
+	var group tvEpisodeGroup
+	found, err := readFixture(f.folder, episodeGroupFixture(groupID), &group)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: no fixture for episode group %s", ErrNotFound, groupID)
+	}
+	return &group, nil
+}
+
+func (r *recordingSource) SearchMoviesYear(query string, year string, page int) (*tmdb.PaginatedMovieResults, error) {
+	results, err := r.source.SearchMoviesYear(query, year, page)
+	if err != nil {
+		return nil, err
+	}
+	writeFixture(r.folder, movieSearchFixture(query, year), results)
+	return results, nil
+}
+
+func (r *recordingSource) GetMovieInfo(id int) (*tmdbapi.Movie, error) {
+	movie, err := r.source.GetMovieInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	writeFixture(r.folder, movieFixture(id), movie)
+	return movie, nil
+}
+
+func (r *recordingSource) SearchTVShows(query string, page int, adult bool) (*tmdb.PaginatedTVShowResults, error) {
+	results, err := r.source.SearchTVShows(query, page, adult)
+	if err != nil {
+		return nil, err
+	}
+	writeFixture(r.folder, tvSearchFixture(query), results)
+	return results, nil
+}
+
+func (r *recordingSource) GetTVEpisode(tvID, season, episodeNumber int) (*tmdb.TVEpisode, error) {
+	episode, err := r.source.GetTVEpisode(tvID, season, episodeNumber)
+	if err != nil {
+		return nil, err
+	}
+	writeFixture(r.folder, episodeFixture(tvID, season, episodeNumber), episode)
+	return episode, nil
+}
+
+func (r *recordingSource) GetTvInfo(id int) (*tmdbapi.TV, error) {
+	tvShow, err := r.source.GetTvInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	writeFixture(r.folder, tvFixture(id), tvShow)
+	return tvShow, nil
+}
+
+func (r *recordingSource) GetTVSeasonEpisodes(tvID int, season int) ([]*tmdb.TVEpisode, error) {
+	episodes, err := r.source.GetTVSeasonEpisodes(tvID, season)
+	if err != nil {
+		return nil, err
+	}
+	writeFixture(r.folder, seasonFixture(tvID, season), episodes)
+	return episodes, nil
+}
+
+func (r *recordingSource) FindByExternalID(id string, source string) (*tmdbapi.FindResults, error) {
+	results, err := r.source.FindByExternalID(id, source)
+	if err != nil {
+		return nil, err
+	}
+	writeFixture(r.folder, findFixture(id, source), results)
+	return results, nil
+}
+
+func (r *recordingSource) GetTVEpisodeGroups(tvID int) ([]*tvEpisodeGroup, error) {
+	groups, err := r.source.GetTVEpisodeGroups(tvID)
+	if err != nil {
+		return nil, err
+	}
+	writeFixture(r.folder, episodeGroupsFixture(tvID), groups)
+	return groups, nil
+}
+
+func (r *recordingSource) GetEpisodeGroup(groupID string) (*tvEpisodeGroup, error) {
+	group, err := r.source.GetEpisodeGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	writeFixture(r.folder, episodeGroupFixture(groupID), group)
+	return group, nil
+}
+
+func movieSearchFixture(query, year string) string {
+	key := fixtureKey(query)
+	if year != "" {
+		key += "_" + year
+	}
+	return filepath.Join("search", "movie", key)
+}
+
+func tvSearchFixture(query string) string {
+	return filepath.Join("search", "tv", fixtureKey(query))
+}
+
+func movieFixture(id int) string {
+	return filepath.Join("movie", strconv.Itoa(id))
+}
+
+func tvFixture(id int) string {
+	return filepath.Join("tv", strconv.Itoa(id))
+}
+
+func seasonFixture(tvID, season int) string {
+	return filepath.Join("tv", strconv.Itoa(tvID), fmt.Sprintf("s%02d", season))
+}
+
+func episodeFixture(tvID, season, episode int) string {
+	return filepath.Join("tv", strconv.Itoa(tvID), fmt.Sprintf("s%02de%02d", season, episode))
+}
+
+func episodeGroupsFixture(tvID int) string {
+	return filepath.Join("tv", strconv.Itoa(tvID), "episode_groups")
+}
+
+func episodeGroupFixture(groupID string) string {
+	return filepath.Join("episode_group", fixtureKey(groupID))
+}
+
+func findFixture(id, source string) string {
+	return filepath.Join("find", source, fixtureKey(id))
+}
+
+// fixtureKey turns a search query into a stable file name, e.g. "Le Dîner de cons" -> "le-diner-de-cons"
+func fixtureKey(query string) string {
+	return normalizeName(query)
+}
+
+// readFixture decodes the fixture stored under name (without extension) into value.
+// It returns false if no fixture exists for this name.
+func readFixture(folder, name string, value any) (bool, error) {
+	for _, extension := range fixtureExtensions {
+		content, err := os.ReadFile(filepath.Join(folder, name+extension))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return false, err
+		}
+		if extension != ".json" {
+			content, err = yamlToJSON(content)
+			if err != nil {
+				return false, fmt.Errorf("invalid fixture %s: %w", name+extension, err)
+			}
+		}
+		if err = json.Unmarshal(content, value); err != nil {
+			return false, fmt.Errorf("invalid fixture %s: %w", name+extension, err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// yamlToJSON converts a YAML document to JSON so that fixtures share the JSON field names whatever their format
+func yamlToJSON(content []byte) ([]byte, error) {
+	var document any
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// writeFixture stores value as a JSON fixture, logging instead of failing since recording must not break the lookup
+func writeFixture(folder, name string, value any) {
+	fixturePath := filepath.Join(folder, name+".json")
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		log.Printf("Failed to encode fixture %s: %v", fixturePath, err)
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(fixturePath), os.ModePerm); err != nil {
+		log.Printf("Failed to create fixture folder %s: %v", filepath.Dir(fixturePath), err)
+		return
+	}
+	if err = os.WriteFile(fixturePath, content, 0644); err != nil {
+		log.Printf("Failed to write fixture %s: %v", fixturePath, err)
+		return
+	}
+	log.Println("Recorded fixture", fixturePath)
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+var fixtureFolder = filepath.Join("testdata", "tmdb")
+
+// TestFixtureMediaClientMovies resolves movie filenames the way the scan does, parsing them then looking them up on the fixtures
+func TestFixtureMediaClientMovies(t *testing.T) {
+	var client = NewFixtureMediaClient(fixtureFolder)
+	var tests = []struct {
+		filename string
+		id       int
+		err      error
+	}{
+		{filename: "The.Matrix.1999.1080p.BluRay.x264-SPARKS.mkv", id: 603},
+		{filename: "Some.Movie.{imdb-tt0133093}.mkv", id: 603},
+		{filename: "Unknown.Movie.2001.1080p.WEB-DL.mkv", err: ErrNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			name, year := SanitizeMovieFilename(test.filename)
+			movie, err := client.SearchMovie(name, year, ParseMediaIDs(test.filename))
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("got error %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if movie.ID != test.id {
+				t.Errorf("got movie %d, want %d", movie.ID, test.id)
+			}
+		})
+	}
+}
+
+func TestFixtureMediaClientMovieDetails(t *testing.T) {
+	movie, err := NewFixtureMediaClient(fixtureFolder).SearchMovie("The Matrix", "1999", MediaIDs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.Name != "Matrix" || movie.OriginalTitle != "The Matrix" || movie.Runtime != 136 || movie.Year() != "1999" {
+		t.Errorf("unexpected movie %+v", movie)
+	}
+	if len(movie.Categories) != 2 || movie.Categories[1].Name != "Science-Fiction" {
+		t.Errorf("unexpected categories %+v", movie.Categories)
+	}
+	if movie.Collection == nil || movie.Collection.ID != 2344 {
+		t.Errorf("unexpected collection %+v", movie.Collection)
+	}
+}
+
+// TestFixtureMediaClientTVShows resolves a TV show filename on YAML fixtures
+func TestFixtureMediaClientTVShows(t *testing.T) {
+	var client = NewFixtureMediaClient(fixtureFolder)
+	name, season, episode, _ := SanitizeTVShowFilename("Breaking.Bad.S02E03.720p.HDTV.x264.mkv")
+	tvEpisode, err := client.SearchTVShow(name, MediaIDs{}, season, episode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tvEpisode.ID != 62094 || tvEpisode.TvShowID != 1396 || tvEpisode.EpisodeName != "Peekaboo" || tvEpisode.Season != 2 || tvEpisode.Episode != 3 {
+		t.Errorf("unexpected episode %+v", tvEpisode)
+	}
+	if len(tvEpisode.Categories) != 1 || tvEpisode.Categories[0].Name != "Drame" {
+		t.Errorf("unexpected categories %+v", tvEpisode.Categories)
+	}
+
+	// The 10th episode overall is the 3rd of season 2, season 1 having 7 episodes
+	tvEpisode, err = client.SearchTVShowAbsolute(name, MediaIDs{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tvEpisode.ID != 62094 {
+		t.Errorf("got episode %d, want 62094", tvEpisode.ID)
+	}
+
+	if _, err = client.SearchTVShow(name, MediaIDs{}, 2, 4); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want %v", err, ErrNotFound)
+	}
+	if _, err = client.SearchTVShow("Unknown Show", MediaIDs{}, 1, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want %v", err, ErrNotFound)
+	}
+}
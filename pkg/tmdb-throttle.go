@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/time/rate"
+	"io"
+	"log"
+	"net"
+	"regexp"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+var statusCodeRegex = regexp.MustCompile(`^Code \((\d+)\)`) // regex to extract the TMDB status code of an error, e.g. "Code (25): Your request count is over the allowed limit"
+
+var transientStatusCodes = map[int]bool{ // TMDB status codes of the failures worth retrying
+	9:  true, // Service offline
+	11: true, // Internal error
+	15: true, // Failed
+	24: true, // Backend server timeout
+	25: true, // Request count over the allowed limit
+	43: true, // Couldn't connect to the backend server
+	46: true, // API undergoing maintenance
+}
+
+// httpStatusError is the failure of a request TMDB answered without status code, e.g. a gateway error page
+type httpStatusError struct {
+	code   int
+	status string
+}
+
+func (e *httpStatusError) Error() string {
+	return "unexpected status " + e.status
+}
+
+// Throttle bounds the rate of the requests sent to TMDB with a token-bucket limiter and retries the requests failing transiently.
+// Each request takes one token, so a lookup going through several requests (search, details, seasons) takes several tokens.
+type Throttle struct {
+	limiter    *rate.Limiter       // Token bucket shared by every request
+	maxRetries int                 // Number of retries after the first failed attempt
+	backoff    time.Duration       // Delay before the first retry, doubled on each following retry
+	sleep      func(time.Duration) // Waits between two attempts, replaced in tests
+}
+
+// NewThrottle returns a Throttle sending at most requestsPerSecond requests per second (with the given burst)
+// and retrying up to maxRetries times with an exponential backoff when the provider fails transiently.
+// It fails if the rate or the burst doesn't let any request through.
+func NewThrottle(requestsPerSecond float64, burst, maxRetries int, backoff time.Duration) (*Throttle, error) {
+	if requestsPerSecond <= 0 {
+		return nil, fmt.Errorf("invalid request rate %v, it must be positive", requestsPerSecond)
+	}
+	if burst < 1 {
+		return nil, fmt.Errorf("invalid request burst %d, it must be at least 1", burst)
+	}
+	return &Throttle{
+		limiter:    rate.NewLimiter(rate.Limit(requestsPerSecond), burst),
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		sleep:      time.Sleep,
+	}, nil
+}
+
+// do sends the request once the limiter allows it, retrying it while it fails transiently.
+// The error of the request is classified by providerError. A nil Throttle sends the request once, right away.
+func (t *Throttle) do(request func() error) error {
+	if t == nil {
+		if err := request(); err != nil {
+			return providerError(err)
+		}
+		return nil
+	}
+	var err error
+	for attempt := 0; attempt <= t.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := t.backoff << (attempt - 1)
+			log.Printf("Retrying request in %v (attempt %d/%d) after error: %v", delay, attempt, t.maxRetries, err)
+			t.sleep(delay)
+		}
+		if err = t.limiter.Wait(context.Background()); err != nil {
+			return fmt.Errorf("%w: %v", ErrProvider, err)
+		}
+		if err = request(); err == nil {
+			return nil
+		}
+		if !isTransient(err) {
+			return providerError(err)
+		}
+		err = providerError(err)
+	}
+	return err
+}
+
+// isTransient tells whether a failed request is worth retrying: rate limited, failed on the server side or not answered.
+// An error answer which is not JSON is most likely the page of a gateway, retried as well.
+// Authentication failures and the other client errors fail the same way on every attempt.
+func isTransient(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code == 429 || statusErr.code >= 500
+	}
+	if matches := statusCodeRegex.FindStringSubmatch(err.Error()); matches != nil {
+		code, _ := strconv.Atoi(matches[1])
+		return transientStatusCodes[code]
+	}
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &netErr) || errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
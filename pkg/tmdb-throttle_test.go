@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// errConnectionRefused is the error of a request TMDB could not be reached for
+var errConnectionRefused = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+func newTestThrottle(maxRetries int, delays *[]time.Duration) *Throttle {
+	throttle, err := NewThrottle(1000, 10, maxRetries, 10*time.Millisecond)
+	if err != nil {
+		panic(err)
+	}
+	throttle.sleep = func(delay time.Duration) {
+		*delays = append(*delays, delay)
+	}
+	return throttle
+}
+
+func TestThrottleRetries(t *testing.T) {
+	var tests = []struct {
+		name     string
+		failures int   // Number of attempts failing before the request succeeds
+		err      error // Error of the failing attempts
+		attempts int
+		delays   []time.Duration
+		expected error
+	}{
+		{name: "success", failures: 0, err: errConnectionRefused, attempts: 1},
+		{name: "transient failure", failures: 2, err: errConnectionRefused, attempts: 3, delays: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}},
+		{name: "persistent failure", failures: 10, err: errConnectionRefused, attempts: 4, delays: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}, expected: ErrProvider},
+		{name: "rate limited", failures: 1, err: errors.New("Code (25): Your request count (41) is over the allowed limit of 40."), attempts: 2, delays: []time.Duration{10 * time.Millisecond}},
+		{name: "gateway error", failures: 1, err: &httpStatusError{code: 502, status: "502 Bad Gateway"}, attempts: 2, delays: []time.Duration{10 * time.Millisecond}},
+		{name: "invalid API key", failures: 10, err: errors.New("Code (7): Invalid API key: You must be granted a valid key."), attempts: 1, expected: ErrProvider},
+		{name: "client error", failures: 10, err: &httpStatusError{code: 400, status: "400 Bad Request"}, attempts: 1, expected: ErrProvider},
+		{name: "not found", failures: 10, err: errors.New("Code (34): The resource you requested could not be found."), attempts: 1, expected: ErrNotFound},
+		{name: "classified not found", failures: 10, err: ErrNotFound, attempts: 1, expected: ErrNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var delays []time.Duration
+			var attempts int
+			err := newTestThrottle(3, &delays).do(func() error {
+				attempts++
+				if attempts <= test.failures {
+					return test.err
+				}
+				return nil
+			})
+			if test.expected == nil && err != nil || !errors.Is(err, test.expected) {
+				t.Errorf("got error %v, want %v", err, test.expected)
+			}
+			if attempts != test.attempts {
+				t.Errorf("got %d attempts, want %d", attempts, test.attempts)
+			}
+			if !reflect.DeepEqual(delays, test.delays) {
+				t.Errorf("got delays %v, want %v", delays, test.delays)
+			}
+		})
+	}
+}
+
+func TestNilThrottle(t *testing.T) {
+	var throttle *Throttle
+	var attempts int
+	err := throttle.do(func() error {
+		attempts++
+		return errConnectionRefused
+	})
+	if !errors.Is(err, ErrProvider) || attempts != 1 {
+		t.Errorf("got error %v after %d attempts, want %v after 1", err, attempts, ErrProvider)
+	}
+}
+
+func TestNewThrottle(t *testing.T) {
+	var tests = []struct {
+		requestsPerSecond float64
+		burst             int
+		ok                bool
+	}{
+		{requestsPerSecond: 2, burst: 4, ok: true},
+		{requestsPerSecond: 0.5, burst: 1, ok: true},
+		{requestsPerSecond: 2, burst: 0},
+		{requestsPerSecond: 0, burst: 4},
+		{requestsPerSecond: -1, burst: 4},
+	}
+	for _, test := range tests {
+		throttle, err := NewThrottle(test.requestsPerSecond, test.burst, 3, time.Second)
+		if (err == nil) != test.ok || (throttle != nil) != test.ok {
+			t.Errorf("NewThrottle(%v, %d) = %v, %v, want ok %t", test.requestsPerSecond, test.burst, throttle, err, test.ok)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	var tests = []struct {
+		err      error
+		expected bool
+	}{
+		{err: errConnectionRefused, expected: true},
+		{err: fmt.Errorf("Get \"https://api.themoviedb.org/3/movie/603\": %w", &net.DNSError{Err: "no such host", IsTimeout: true}), expected: true},
+		{err: &json.SyntaxError{}, expected: true},
+		{err: errors.New("Code (25): Your request count (41) is over the allowed limit of 40."), expected: true},
+		{err: errors.New("Code (11): Internal error: Something went wrong, contact TMDb."), expected: true},
+		{err: &httpStatusError{code: 429, status: "429 Too Many Requests"}, expected: true},
+		{err: &httpStatusError{code: 503, status: "503 Service Unavailable"}, expected: true},
+		{err: errors.New("Code (7): Invalid API key: You must be granted a valid key.")},
+		{err: errors.New("Code (3): Authentication failed: You do not have permissions to access the service.")},
+		{err: errors.New("Code (34): The resource you requested could not be found.")},
+		{err: &httpStatusError{code: 401, status: "401 Unauthorized"}},
+		{err: &httpStatusError{code: 404, status: "404 Not Found"}},
+		{err: ErrNotFound},
+	}
+	for _, test := range tests {
+		if result := isTransient(test.err); result != test.expected {
+			t.Errorf("isTransient(%v) = %t, want %t", test.err, result, test.expected)
+		}
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	var tests = []struct {
+		err      error
+		expected error
+	}{
+		{err: errors.New("Code (34): The resource you requested could not be found."), expected: ErrNotFound},
+		{err: errors.New("Code (7): Invalid API key"), expected: ErrProvider},
+		{err: errors.New("dial tcp: i/o timeout"), expected: ErrProvider},
+		{err: ErrNotFound, expected: ErrNotFound},
+		{err: ErrProvider, expected: ErrProvider},
+	}
+	for _, test := range tests {
+		err := providerError(test.err)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("providerError(%v) = %v, want %v", test.err, err, test.expected)
+		}
+		if test.expected == ErrNotFound && errors.Is(err, ErrProvider) {
+			t.Errorf("providerError(%v) = %v, classified as both", test.err, err)
+		}
+	}
+}
@@ -2,11 +2,23 @@ package pkg
 
 import (
 	"errors"
+	"fmt"
 	"github.com/bingemate/media-go-pkg/tmdb"
+	tmdbapi "github.com/ryanbradynd05/go-tmdb"
+	"log"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
 
+var (
+	// ErrNotFound is returned when the metadata provider has no match for a lookup
+	ErrNotFound = errors.New("no results found")
+	// ErrProvider is returned when the metadata provider could not be reached or failed to answer a lookup
+	ErrProvider = errors.New("metadata provider error")
+)
+
 type Category struct {
 	ID   int
 	Name string
@@ -19,8 +31,23 @@ type Media struct {
 	Categories  []Category
 }
 
+type Collection struct {
+	ID           int
+	Name         string
+	PosterPath   string
+	BackdropPath string
+}
+
 type Movie struct {
 	Media
+	OriginalTitle    string
+	Overview         string
+	Runtime          int // Runtime in minutes
+	OriginalLanguage string
+	PosterPath       string
+	BackdropPath     string
+	ImdbID           string
+	Collection       *Collection // Collection the movie belongs to, nil if none
 }
 
 type TVEpisode struct {
@@ -35,6 +62,28 @@ type TVEpisode struct {
 	Episode       int
 }
 
+type TVSeason struct {
+	ID           int
+	Number       int
+	Name         string
+	AirDate      string
+	EpisodeCount int
+	PosterPath   string
+}
+
+type TVShow struct {
+	Media
+	OriginalName string
+	Overview     string
+	Status       string // TMDB status of the show (e.g. Ended, Returning Series, Canceled)
+	Network      string // Name of the first network airing the show
+	SeasonCount  int
+	EpisodeCount int
+	PosterPath   string
+	BackdropPath string
+	Seasons      []TVSeason
+}
+
 func (m *Media) Year() string {
 	return strings.Split(m.ReleaseDate, "-")[0]
 }
@@ -43,30 +92,32 @@ func (m *TVEpisode) Year() string {
 	return strings.Split(m.ReleaseDate, "-")[0]
 }
 
+// AtomicMovieList links movie files to their TMDB movie, keyed by pointer since MovieFile isn't comparable
 type AtomicMovieList struct {
-	mediaList map[MovieFile]Movie
+	mediaList map[*MovieFile]Movie
 	lock      sync.Mutex
 }
 
+// AtomicTVEpisodeList links TV show files to their TMDB episodes, several for multi-episode files, keyed by pointer since TVShowFile isn't comparable
 type AtomicTVEpisodeList struct {
-	mediaList map[TVShowFile]TVEpisode
+	mediaList map[*TVShowFile][]TVEpisode
 	lock      sync.Mutex
 }
 
-func (a *AtomicMovieList) LinkMediaFile(mediaFile MovieFile, media Movie) {
+func (a *AtomicMovieList) LinkMediaFile(mediaFile *MovieFile, media Movie) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	a.mediaList[mediaFile] = media
 }
 
-func (a *AtomicMovieList) Get(mediaFile MovieFile) (Movie, bool) {
+func (a *AtomicMovieList) Get(mediaFile *MovieFile) (Movie, bool) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	media, ok := a.mediaList[mediaFile]
 	return media, ok
 }
 
-func (a *AtomicMovieList) GetAll() map[MovieFile]Movie {
+func (a *AtomicMovieList) GetAll() map[*MovieFile]Movie {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	return a.mediaList
@@ -74,25 +125,25 @@ func (a *AtomicMovieList) GetAll() map[MovieFile]Movie {
 
 func NewAtomicMovieList() *AtomicMovieList {
 	return &AtomicMovieList{
-		mediaList: make(map[MovieFile]Movie),
+		mediaList: make(map[*MovieFile]Movie),
 		lock:      sync.Mutex{},
 	}
 }
 
-func (a *AtomicTVEpisodeList) LinkMediaFile(mediaFile TVShowFile, media TVEpisode) {
+func (a *AtomicTVEpisodeList) LinkMediaFile(mediaFile *TVShowFile, media []TVEpisode) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	a.mediaList[mediaFile] = media
 }
 
-func (a *AtomicTVEpisodeList) Get(mediaFile TVShowFile) (TVEpisode, bool) {
+func (a *AtomicTVEpisodeList) Get(mediaFile *TVShowFile) ([]TVEpisode, bool) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	media, ok := a.mediaList[mediaFile]
 	return media, ok
 }
 
-func (a *AtomicTVEpisodeList) GetAll() map[TVShowFile]TVEpisode {
+func (a *AtomicTVEpisodeList) GetAll() map[*TVShowFile][]TVEpisode {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	return a.mediaList
@@ -100,33 +151,171 @@ func (a *AtomicTVEpisodeList) GetAll() map[TVShowFile]TVEpisode {
 
 func NewAtomicTVEpisodeList() *AtomicTVEpisodeList {
 	return &AtomicTVEpisodeList{
-		mediaList: make(map[TVShowFile]TVEpisode),
+		mediaList: make(map[*TVShowFile][]TVEpisode),
 		lock:      sync.Mutex{},
 	}
 }
 
+// MediaClient resolves media files on TMDB.
+// The search methods look the media up directly when ids holds one of its IDs, and search its title otherwise.
 type MediaClient interface {
-	SearchMovie(query string, year string) (Movie, error)
-	SearchTVShow(query string, season, episode int) (TVEpisode, error)
+	SearchMovie(query string, year string, ids MediaIDs) (Movie, error)
+	SearchTVShow(query string, ids MediaIDs, season, episode int) (TVEpisode, error)
+	SearchTVShowAbsolute(query string, ids MediaIDs, absoluteEpisode int) (TVEpisode, error)
+	SearchTVShowByDate(query string, ids MediaIDs, airDate string) (TVEpisode, error)
+	SearchTVShowSpecial(query string, ids MediaIDs, name string) (TVEpisode, error)
+	GetTVShow(id int) (TVShow, error)
+	GetTVSeasonEpisodes(tvShowID, season int) ([]TVEpisode, error)
+}
+
+// metadataSource is the subset of the TMDB client used to resolve media files.
+// It is satisfied by tmdbSource, which queries TMDB, as well as by the fixture backed sources.
+type metadataSource interface {
+	SearchMoviesYear(query string, year string, page int) (*tmdb.PaginatedMovieResults, error)
+	GetMovieInfo(id int) (*tmdbapi.Movie, error)
+	SearchTVShows(query string, page int, adult bool) (*tmdb.PaginatedTVShowResults, error)
+	GetTVEpisode(tvID, season, episodeNumber int) (*tmdb.TVEpisode, error)
+	GetTvInfo(id int) (*tmdbapi.TV, error)
+	GetTVSeasonEpisodes(tvID int, season int) ([]*tmdb.TVEpisode, error)
+	FindByExternalID(id string, source string) (*tmdbapi.FindResults, error)
+	GetTVEpisodeGroups(tvID int) ([]*tvEpisodeGroup, error)
+	GetEpisodeGroup(groupID string) (*tvEpisodeGroup, error)
+}
+
+// tmdbSource resolves lookups on TMDB, through the bingemate client (and its cache) for searches and episodes
+// and through the TMDB API, cached by the source itself, for the details the bingemate client does not expose.
+// Every request goes through the throttle, which bounds the rate of the requests and retries the failed ones.
+type tmdbSource struct {
+	client   tmdb.MediaClient
+	api      *tmdbapi.TMDb
+	apiKey   string
+	cache    detailsCache
+	throttle *Throttle
 }
 
 type mediaClient struct {
-	client tmdb.MediaClient
+	client metadataSource
 }
 
-func NewMediaClient(apiKey string) MediaClient {
+// NewMediaClient returns a MediaClient querying TMDB, its requests going through the given throttle (nil to send them right away)
+func NewMediaClient(apiKey string, throttle *Throttle) MediaClient {
 	return &mediaClient{
-		client: tmdb.NewMediaClient(apiKey),
+		client: newTMDBSource(tmdb.NewMediaClient(apiKey), newMemoryDetailsCache(), apiKey, throttle),
 	}
 }
 
-func NewRedisMediaClient(apiKey, redisHost, redisPassword string) MediaClient {
+// NewRedisMediaClient is NewMediaClient with the lookups cached in redis
+func NewRedisMediaClient(apiKey, redisHost, redisPassword string, throttle *Throttle) MediaClient {
 	return &mediaClient{
-		client: tmdb.NewRedisMediaClient(apiKey, redisHost, redisPassword),
+		client: newTMDBSource(tmdb.NewRedisMediaClient(apiKey, redisHost, redisPassword), newRedisDetailsCache(redisHost, redisPassword), apiKey, throttle),
+	}
+}
+
+func newTMDBSource(client tmdb.MediaClient, cache detailsCache, apiKey string, throttle *Throttle) *tmdbSource {
+	return &tmdbSource{
+		client:   client,
+		api:      tmdbapi.Init(tmdbapi.Config{APIKey: apiKey}),
+		apiKey:   apiKey,
+		cache:    cache,
+		throttle: throttle,
 	}
 }
 
-func (m *mediaClient) SearchMovie(query string, year string) (Movie, error) {
+func (t *tmdbSource) SearchMoviesYear(query string, year string, page int) (*tmdb.PaginatedMovieResults, error) {
+	var results *tmdb.PaginatedMovieResults
+	err := t.throttle.do(func() error {
+		var err error
+		results, err = t.client.SearchMoviesYear(query, year, page)
+		return err
+	})
+	return results, err
+}
+
+func (t *tmdbSource) GetMovieInfo(id int) (*tmdbapi.Movie, error) {
+	var key = fmt.Sprintf("indexer_movie_info:%d", id)
+	var movie *tmdbapi.Movie
+	if t.cache.get(key, &movie) {
+		return movie, nil
+	}
+	err := t.throttle.do(func() error {
+		var err error
+		movie, err = t.api.GetMovieInfo(id, map[string]string{"language": "fr"})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.cache.set(key, movie, movieInfoExpiration)
+	return movie, nil
+}
+
+func (t *tmdbSource) SearchTVShows(query string, page int, adult bool) (*tmdb.PaginatedTVShowResults, error) {
+	var results *tmdb.PaginatedTVShowResults
+	err := t.throttle.do(func() error {
+		var err error
+		results, err = t.client.SearchTVShows(query, page, adult)
+		return err
+	})
+	return results, err
+}
+
+func (t *tmdbSource) GetTVEpisode(tvID, season, episodeNumber int) (*tmdb.TVEpisode, error) {
+	var episode *tmdb.TVEpisode
+	err := t.throttle.do(func() error {
+		var err error
+		episode, err = t.client.GetTVEpisode(tvID, season, episodeNumber)
+		return err
+	})
+	return episode, err
+}
+
+func (t *tmdbSource) GetTvInfo(id int) (*tmdbapi.TV, error) {
+	var key = fmt.Sprintf("indexer_tv_info:%d", id)
+	var tvShow *tmdbapi.TV
+	if t.cache.get(key, &tvShow) {
+		return tvShow, nil
+	}
+	err := t.throttle.do(func() error {
+		var err error
+		tvShow, err = t.api.GetTvInfo(id, map[string]string{"language": "fr"})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.cache.set(key, tvShow, tvInfoExpiration)
+	return tvShow, nil
+}
+
+func (t *tmdbSource) GetTVSeasonEpisodes(tvID int, season int) ([]*tmdb.TVEpisode, error) {
+	var episodes []*tmdb.TVEpisode
+	err := t.throttle.do(func() error {
+		var err error
+		episodes, err = t.client.GetTVSeasonEpisodes(tvID, season)
+		return err
+	})
+	return episodes, err
+}
+
+func (t *tmdbSource) FindByExternalID(id string, source string) (*tmdbapi.FindResults, error) {
+	var key = fmt.Sprintf("indexer_find:%s:%s", source, id)
+	var results *tmdbapi.FindResults
+	if t.cache.get(key, &results) {
+		return results, nil
+	}
+	err := t.throttle.do(func() error {
+		var err error
+		results, err = t.api.GetFind(id, source, map[string]string{"language": "fr"})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.cache.set(key, results, findExpiration)
+	return results, nil
+}
+
+func (m *mediaClient) SearchMovie(query string, year string, ids MediaIDs) (Movie, error) {
 	/*var options = make(map[string]string)
 	options["language"] = "fr"
 	if year != "" {
@@ -144,16 +333,29 @@ func (m *mediaClient) SearchMovie(query string, year string) (Movie, error) {
 	if err != nil {
 		return Movie{}, err
 	}*/
-	results, err := m.client.SearchMoviesYear(query, year, 1)
+	movieID, err := m.resolveMovieID(ids)
 	if err != nil {
 		return Movie{}, err
 	}
-	if results.TotalResult == 0 {
-		return Movie{}, errors.New("no results found")
+	if movieID == 0 {
+		results, err := m.client.SearchMoviesYear(query, year, 1)
+		if err != nil {
+			return Movie{}, providerError(err)
+		}
+		if results.TotalResult == 0 {
+			return Movie{}, ErrNotFound
+		}
+		movieID = results.Results[0].ID
 	}
-	var media = results.Results[0]
-	movieInfo, err := m.client.GetMovieShort(media.ID)
+	movieInfo, err := m.client.GetMovieInfo(movieID)
+	if err != nil {
+		return Movie{}, providerError(err)
+	}
+	return extractMovie(movieInfo), nil
+}
 
+// extractMovie converts the TMDB details of a movie to a Movie
+func extractMovie(movieInfo *tmdbapi.Movie) Movie {
 	var categories = make([]Category, 0)
 	for _, genre := range movieInfo.Genres {
 		categories = append(categories,
@@ -163,17 +365,35 @@ func (m *mediaClient) SearchMovie(query string, year string) (Movie, error) {
 			})
 	}
 
+	var collection *Collection
+	if movieInfo.BelongsToCollection.ID != 0 {
+		collection = &Collection{
+			ID:           movieInfo.BelongsToCollection.ID,
+			Name:         movieInfo.BelongsToCollection.Name,
+			PosterPath:   movieInfo.BelongsToCollection.PosterPath,
+			BackdropPath: movieInfo.BelongsToCollection.BackdropPath,
+		}
+	}
+
 	return Movie{
 		Media: Media{
-			ID:          media.ID,
-			Name:        media.Title,
-			ReleaseDate: media.ReleaseDate,
+			ID:          movieInfo.ID,
+			Name:        movieInfo.Title,
+			ReleaseDate: movieInfo.ReleaseDate,
 			Categories:  categories,
 		},
-	}, nil
+		OriginalTitle:    movieInfo.OriginalTitle,
+		Overview:         movieInfo.Overview,
+		Runtime:          int(movieInfo.Runtime),
+		OriginalLanguage: movieInfo.OriginalLanguage,
+		PosterPath:       movieInfo.PosterPath,
+		BackdropPath:     movieInfo.BackdropPath,
+		ImdbID:           movieInfo.ImdbID,
+		Collection:       collection,
+	}
 }
 
-func (m *mediaClient) SearchTVShow(query string, season, episode int) (TVEpisode, error) {
+func (m *mediaClient) SearchTVShow(query string, ids MediaIDs, season, episode int) (TVEpisode, error) {
 	/*	var options = make(map[string]string)
 		options["language"] = "fr"
 		show, err := m.tmdbClient.SearchTv(query, options)
@@ -191,17 +411,219 @@ func (m *mediaClient) SearchTVShow(query string, season, episode int) (TVEpisode
 		if err != nil {
 			return TVEpisode{}, err
 		}*/
-	tvShows, err := m.client.SearchTVShows(query, 1, false)
+	show, err := m.findTVShow(query, ids)
+	if err != nil {
+		return TVEpisode{}, err
+	}
+	return m.getTVEpisode(show, season, episode)
+}
+
+// SearchTVShowAbsolute searches for a TV show episode by its absolute number, as anime releases do (e.g. One Piece - 1071),
+// and maps it to its TMDB season and episode. The absolute episode group of the show is followed when TMDB has one,
+// as the cours of an anime are often split or merged differently in its seasons, and the episodes are counted across
+// the regular seasons otherwise.
+func (m *mediaClient) SearchTVShowAbsolute(query string, ids MediaIDs, absoluteEpisode int) (TVEpisode, error) {
+	show, err := m.findTVShow(query, ids)
 	if err != nil {
 		return TVEpisode{}, err
 	}
+	group, err := m.findAbsoluteEpisodeGroup(show.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return TVEpisode{}, err
+	}
+	if group != nil {
+		if season, episode, ok := mapAbsoluteEpisodeInGroup(group, absoluteEpisode); ok {
+			return m.getTVEpisode(show, season, episode)
+		}
+		log.Printf("Absolute episode %d is beyond the episode group %s of %s, counting the episodes of the seasons", absoluteEpisode, group.Name, show.Title)
+	}
+	tvShow, err := m.GetTVShow(show.ID)
+	if err != nil {
+		return TVEpisode{}, err
+	}
+	season, episode, ok := MapAbsoluteEpisode(tvShow.Seasons, absoluteEpisode)
+	if !ok {
+		return TVEpisode{}, fmt.Errorf("%w: absolute episode %d is beyond the %d episodes of %s", ErrNotFound, absoluteEpisode, tvShow.EpisodeCount, tvShow.Name)
+	}
+	return m.getTVEpisode(show, season, episode)
+}
+
+// SearchTVShowByDate searches for the episode of a daily TV show (talk show, news) aired on the given date (e.g. 2023-05-14),
+// looking through the episode listings of the regular seasons aired before that date, the most recent first.
+func (m *mediaClient) SearchTVShowByDate(query string, ids MediaIDs, airDate string) (TVEpisode, error) {
+	show, err := m.findTVShow(query, ids)
+	if err != nil {
+		return TVEpisode{}, err
+	}
+	tvShow, err := m.GetTVShow(show.ID)
+	if err != nil {
+		return TVEpisode{}, err
+	}
+	var seasons = make([]TVSeason, 0, len(tvShow.Seasons))
+	for _, season := range tvShow.Seasons {
+		// TMDB dates are ISO formatted, so they can be compared as strings
+		if season.Number > 0 && season.AirDate <= airDate {
+			seasons = append(seasons, season)
+		}
+	}
+	sort.Slice(seasons, func(i, j int) bool {
+		return seasons[i].Number > seasons[j].Number
+	})
+	for _, season := range seasons {
+		episodes, err := m.GetTVSeasonEpisodes(tvShow.ID, season.Number)
+		if err != nil {
+			return TVEpisode{}, err
+		}
+		for _, episode := range episodes {
+			if episode.ReleaseDate == airDate {
+				return m.getTVEpisode(show, episode.Season, episode.Episode)
+			}
+		}
+	}
+	return TVEpisode{}, fmt.Errorf("%w: no episode of %s aired on %s", ErrNotFound, tvShow.Name, airDate)
+}
+
+// SearchTVShowSpecial searches for a special (season 0) of a TV show by its name, e.g. "Christmas" for "The Christmas Invasion".
+// An exact name is preferred to a partial one.
+func (m *mediaClient) SearchTVShowSpecial(query string, ids MediaIDs, name string) (TVEpisode, error) {
+	show, err := m.findTVShow(query, ids)
+	if err != nil {
+		return TVEpisode{}, err
+	}
+	specials, err := m.GetTVSeasonEpisodes(show.ID, 0)
+	if err != nil {
+		return TVEpisode{}, err
+	}
+	var key = normalizeName(name)
+	var match *TVEpisode
+	for i, special := range specials {
+		specialKey := normalizeName(special.EpisodeName)
+		if specialKey == key {
+			match = &specials[i]
+			break
+		}
+		if match == nil && key != "" && strings.Contains(specialKey, key) {
+			match = &specials[i]
+		}
+	}
+	if match == nil {
+		return TVEpisode{}, fmt.Errorf("%w: no special of %s named %s", ErrNotFound, show.Title, name)
+	}
+	return m.getTVEpisode(show, 0, match.Episode)
+}
+
+// MapAbsoluteEpisode converts an absolute episode number to a season and episode number using the cumulative episode counts
+// of the regular seasons, for the shows without absolute episode group. It returns false if the number is beyond the last known episode.
+func MapAbsoluteEpisode(seasons []TVSeason, absoluteEpisode int) (int, int, bool) {
+	var regularSeasons = make([]TVSeason, 0, len(seasons))
+	for _, season := range seasons {
+		// Specials are not counted in the absolute numbering
+		if season.Number > 0 {
+			regularSeasons = append(regularSeasons, season)
+		}
+	}
+	sort.Slice(regularSeasons, func(i, j int) bool {
+		return regularSeasons[i].Number < regularSeasons[j].Number
+	})
+	var remaining = absoluteEpisode
+	for _, season := range regularSeasons {
+		if remaining <= season.EpisodeCount {
+			return season.Number, remaining, remaining > 0
+		}
+		remaining -= season.EpisodeCount
+	}
+	return 0, 0, false
+}
+
+// resolveMovieID returns the TMDB ID of the movie tagged by the IDs, 0 if there is none or if the IMDb ID is unknown to TMDB
+func (m *mediaClient) resolveMovieID(ids MediaIDs) (int, error) {
+	if ids.TMDB != 0 {
+		return ids.TMDB, nil
+	}
+	if ids.IMDB == "" {
+		return 0, nil
+	}
+	results, err := m.client.FindByExternalID(ids.IMDB, "imdb_id")
+	if err != nil {
+		return 0, providerError(err)
+	}
+	if len(results.MovieResults) == 0 {
+		log.Printf("No movie found on TMDB for IMDb ID %s, searching by title", ids.IMDB)
+		return 0, nil
+	}
+	return results.MovieResults[0].ID, nil
+}
+
+// findTVShow returns the TV show tagged by the IDs if any, or the first result of the title search otherwise
+func (m *mediaClient) findTVShow(query string, ids MediaIDs) (*tmdb.TVShow, error) {
+	tvShowID, err := m.resolveTVShowID(ids)
+	if err != nil {
+		return nil, err
+	}
+	if tvShowID != 0 {
+		tvInfo, err := m.client.GetTvInfo(tvShowID)
+		if err != nil {
+			return nil, providerError(err)
+		}
+		var genres = make([]tmdb.Genre, len(tvInfo.Genres))
+		for i, genre := range tvInfo.Genres {
+			genres[i] = tmdb.Genre{ID: genre.ID, Name: genre.Name}
+		}
+		return &tmdb.TVShow{
+			ID:          tvInfo.ID,
+			Title:       tvInfo.Name,
+			ReleaseDate: tvInfo.FirstAirDate,
+			Genres:      genres,
+		}, nil
+	}
+	tvShows, err := m.client.SearchTVShows(query, 1, false)
+	if err != nil {
+		return nil, providerError(err)
+	}
 	if tvShows.TotalResult == 0 {
-		return TVEpisode{}, errors.New("no results found")
+		return nil, ErrNotFound
+	}
+	return tvShows.Results[0], nil
+}
+
+// externalID is an ID of a media on another database than TMDB, looked up with the find endpoint of TMDB
+type externalID struct {
+	source string // External source of the find endpoint, e.g. imdb_id
+	id     string
+}
+
+// resolveTVShowID returns the TMDB ID of the TV show tagged by the IDs, 0 if there is none or if the IMDb and TVDB IDs are unknown to TMDB.
+// The IMDb ID is looked up first, then the TVDB ID.
+func (m *mediaClient) resolveTVShowID(ids MediaIDs) (int, error) {
+	if ids.TMDB != 0 {
+		return ids.TMDB, nil
+	}
+	var externalIDs = make([]externalID, 0, 2)
+	if ids.IMDB != "" {
+		externalIDs = append(externalIDs, externalID{source: "imdb_id", id: ids.IMDB})
 	}
-	tvShow := tvShows.Results[0]
+	if ids.TVDB != 0 {
+		externalIDs = append(externalIDs, externalID{source: "tvdb_id", id: strconv.Itoa(ids.TVDB)})
+	}
+	for _, external := range externalIDs {
+		source, id := external.source, external.id
+		results, err := m.client.FindByExternalID(id, source)
+		if err != nil {
+			return 0, providerError(err)
+		}
+		if len(results.TvResults) > 0 {
+			return results.TvResults[0].ID, nil
+		}
+		log.Printf("No TV show found on TMDB for %s %s", source, id)
+	}
+	return 0, nil
+}
+
+// getTVEpisode retrieves the details of an episode of a TV show found by a search
+func (m *mediaClient) getTVEpisode(tvShow *tmdb.TVShow, season, episode int) (TVEpisode, error) {
 	episodeInfo, err := m.client.GetTVEpisode(tvShow.ID, season, episode)
 	if err != nil {
-		return TVEpisode{}, err
+		return TVEpisode{}, providerError(err)
 	}
 
 	var categories = make([]Category, 0)
@@ -225,3 +647,87 @@ func (m *mediaClient) SearchTVShow(query string, season, episode int) (TVEpisode
 		Episode:       episode,
 	}, nil
 }
+
+// GetTVShow retrieves the show-level details of a TV show, including its seasons
+func (m *mediaClient) GetTVShow(id int) (TVShow, error) {
+	tvInfo, err := m.client.GetTvInfo(id)
+	if err != nil {
+		return TVShow{}, providerError(err)
+	}
+
+	var categories = make([]Category, 0)
+	for _, genre := range tvInfo.Genres {
+		categories = append(categories,
+			Category{
+				ID:   genre.ID,
+				Name: genre.Name,
+			})
+	}
+
+	var seasons = make([]TVSeason, len(tvInfo.Seasons))
+	for i, season := range tvInfo.Seasons {
+		seasons[i] = TVSeason{
+			ID:           season.ID,
+			Number:       season.SeasonNumber,
+			Name:         season.Name,
+			AirDate:      season.AirDate,
+			EpisodeCount: season.EpisodeCount,
+			PosterPath:   season.PosterPath,
+		}
+	}
+
+	var network string
+	if len(tvInfo.Networks) > 0 {
+		network = tvInfo.Networks[0].Name
+	}
+
+	return TVShow{
+		Media: Media{
+			ID:          tvInfo.ID,
+			Name:        tvInfo.Name,
+			ReleaseDate: tvInfo.FirstAirDate,
+			Categories:  categories,
+		},
+		OriginalName: tvInfo.OriginalName,
+		Overview:     tvInfo.Overview,
+		Status:       tvInfo.Status,
+		Network:      network,
+		SeasonCount:  tvInfo.NumberOfSeasons,
+		EpisodeCount: tvInfo.NumberOfEpisodes,
+		PosterPath:   tvInfo.PosterPath,
+		BackdropPath: tvInfo.BackdropPath,
+		Seasons:      seasons,
+	}, nil
+}
+
+// GetTVSeasonEpisodes retrieves every episode of a TV show season as listed by TMDB, aired or not
+func (m *mediaClient) GetTVSeasonEpisodes(tvShowID, season int) ([]TVEpisode, error) {
+	episodesInfo, err := m.client.GetTVSeasonEpisodes(tvShowID, season)
+	if err != nil {
+		return nil, providerError(err)
+	}
+	var episodes = make([]TVEpisode, len(episodesInfo))
+	for i, episodeInfo := range episodesInfo {
+		episodes[i] = TVEpisode{
+			ID:          episodeInfo.ID,
+			EpisodeName: episodeInfo.Name,
+			ReleaseDate: episodeInfo.AirDate,
+			TvShowID:    tvShowID,
+			Season:      episodeInfo.SeasonNumber,
+			Episode:     episodeInfo.EpisodeNumber,
+		}
+	}
+	return episodes, nil
+}
+
+// providerError classifies an error returned by the metadata source as either ErrNotFound or ErrProvider
+func providerError(err error) error {
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrProvider) {
+		return err
+	}
+	// TMDB answers with status code 34 when the requested resource does not exist
+	if strings.HasPrefix(err.Error(), "Code (34)") {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return fmt.Errorf("%w: %v", ErrProvider, err)
+}
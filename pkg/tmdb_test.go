@@ -0,0 +1,120 @@
+package pkg
+
+import (
+	tmdbapi "github.com/ryanbradynd05/go-tmdb"
+	"testing"
+)
+
+func TestMapAbsoluteEpisode(t *testing.T) {
+	var seasons = []TVSeason{
+		{Number: 2, EpisodeCount: 12},
+		{Number: 0, EpisodeCount: 4},
+		{Number: 1, EpisodeCount: 25},
+	}
+	var tests = []struct {
+		absolute int
+		season   int
+		episode  int
+		ok       bool
+	}{
+		{absolute: 1, season: 1, episode: 1, ok: true},
+		{absolute: 25, season: 1, episode: 25, ok: true},
+		{absolute: 26, season: 2, episode: 1, ok: true},
+		{absolute: 37, season: 2, episode: 12, ok: true},
+		{absolute: 38, ok: false},
+		{absolute: 0, season: 1, episode: 0, ok: false},
+	}
+	for _, test := range tests {
+		season, episode, ok := MapAbsoluteEpisode(seasons, test.absolute)
+		if season != test.season || episode != test.episode || ok != test.ok {
+			t.Errorf("MapAbsoluteEpisode(%d) = %d, %d, %t, want %d, %d, %t", test.absolute, season, episode, ok, test.season, test.episode, test.ok)
+		}
+	}
+}
+
+func TestMapAbsoluteEpisodeInGroup(t *testing.T) {
+	// The second cour of season 1 is listed by TMDB as season 2, and the recap S01E13 is not numbered in the absolute order
+	var group = &tvEpisodeGroup{
+		Groups: []tvEpisodeSubgroup{
+			{Order: 1, Episodes: []tvEpisodeGroupEntry{
+				{SeasonNumber: 2, EpisodeNumber: 2, Order: 1},
+				{SeasonNumber: 2, EpisodeNumber: 1, Order: 0},
+			}},
+			{Order: 0, Episodes: []tvEpisodeGroupEntry{
+				{SeasonNumber: 1, EpisodeNumber: 12, Order: 0},
+				{SeasonNumber: 1, EpisodeNumber: 14, Order: 1},
+			}},
+		},
+	}
+	var tests = []struct {
+		absolute int
+		season   int
+		episode  int
+		ok       bool
+	}{
+		{absolute: 1, season: 1, episode: 12, ok: true},
+		{absolute: 2, season: 1, episode: 14, ok: true},
+		{absolute: 3, season: 2, episode: 1, ok: true},
+		{absolute: 4, season: 2, episode: 2, ok: true},
+		{absolute: 5, ok: false},
+		{absolute: 0, ok: false},
+	}
+	for _, test := range tests {
+		season, episode, ok := mapAbsoluteEpisodeInGroup(group, test.absolute)
+		if season != test.season || episode != test.episode || ok != test.ok {
+			t.Errorf("mapAbsoluteEpisodeInGroup(%d) = %d, %d, %t, want %d, %d, %t", test.absolute, season, episode, ok, test.season, test.episode, test.ok)
+		}
+	}
+}
+
+// TestSearchTVShowAbsolute follows the absolute episode group of the fixtures, falling back to the seasons beyond it
+func TestSearchTVShowAbsolute(t *testing.T) {
+	var client = NewFixtureMediaClient(fixtureFolder)
+	var tests = []struct {
+		filename string
+		id       int
+	}{
+		{filename: "[SubsPlease] Attack on Titan - 03 (1080p).mkv", id: 1429014},
+		{filename: "[SubsPlease] Attack on Titan - 05 (1080p).mkv", id: 1429021},
+		{filename: "[SubsPlease] Attack on Titan - 08 (1080p).mkv", id: 1429023},
+	}
+	for _, test := range tests {
+		name, _, episode, ok := SanitizeAnimeFilename(test.filename, AnimeModeAuto)
+		if !ok {
+			t.Fatalf("%s is not parsed as an anime episode", test.filename)
+		}
+		tvEpisode, err := client.SearchTVShowAbsolute(name, MediaIDs{}, episode)
+		if err != nil {
+			t.Fatalf("%s: %v", test.filename, err)
+		}
+		if tvEpisode.ID != test.id {
+			t.Errorf("%s: got episode %d, want %d", test.filename, tvEpisode.ID, test.id)
+		}
+	}
+}
+
+// findRecorder records the external sources looked up on the find endpoint, which knows none of the IDs
+type findRecorder struct {
+	metadataSource
+	sources []string
+}
+
+func (f *findRecorder) FindByExternalID(_ string, source string) (*tmdbapi.FindResults, error) {
+	f.sources = append(f.sources, source)
+	return &tmdbapi.FindResults{}, nil
+}
+
+// TestResolveTVShowIDOrder checks that the IMDb ID of a TV show is always looked up before its TVDB ID
+func TestResolveTVShowIDOrder(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var recorder = &findRecorder{}
+		var client = &mediaClient{client: recorder}
+		id, err := client.resolveTVShowID(MediaIDs{IMDB: "tt0903747", TVDB: 81189})
+		if err != nil || id != 0 {
+			t.Fatalf("resolveTVShowID = %d, %v, want 0, nil", id, err)
+		}
+		if len(recorder.sources) != 2 || recorder.sources[0] != "imdb_id" || recorder.sources[1] != "tvdb_id" {
+			t.Fatalf("looked up %q, want [imdb_id tvdb_id]", recorder.sources)
+		}
+	}
+}
@@ -2,10 +2,12 @@ package pkg
 
 import (
 	"fmt"
+	mimetype2 "github.com/gabriel-vasile/mimetype"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type MovieFile struct {
@@ -14,114 +16,516 @@ type MovieFile struct {
 	SanitizedName string
 	Year          string
 	Extension     string
+	Release       Release        // Quality, source and language tags parsed from the filename
+	Folders       []string       // Parent folders of the file, from the scanned source (excluded) down to its own folder
+	IDs           MediaIDs       // Provider IDs tagged in the names of the file and its folders
+	NFO           string         // Path of the NFO sidecar the title, year and IDs were taken from, if any
+	Subtitles     []SubtitleFile // Sidecar subtitles matched to the file by basename
+	ContentHash   string         // Partial hash of the file content used to detect duplicates, set by HashContent, empty if it could not be read
+	Disc          *Disc          // Main feature of a disc folder, the folder being given as filename, nil for a file
 }
 
 func (m *MovieFile) String() string {
 	return fmt.Sprintf("%-100s --> %s", m.Filename, m.SanitizedName)
 }
 
+// MatchHints returns the inputs of the lookup of the file, to tell when they change
+func (m *MovieFile) MatchHints() string {
+	return fmt.Sprintf("%s|%s|%+v", m.SanitizedName, m.Year, m.IDs)
+}
+
+// Input returns what to transcode, the file itself or the main feature of a disc
+func (m *MovieFile) Input() string {
+	if m.Disc != nil {
+		return m.Disc.Input()
+	}
+	return filepath.Join(m.Path, m.Filename)
+}
+
 type TVShowFile struct {
 	Path          string
 	SanitizedName string
 	Season        int
 	Episode       int
+	EpisodeEnd    int    // Last episode of a multi-episode file, same as Episode otherwise
+	Absolute      bool   // Episode numbers are counted across seasons (anime), the season is resolved on TMDB
+	AirDate       string // Air date of a daily show episode (e.g. 2023-05-14), the season and episode are resolved on TMDB
+	SpecialName   string // Name of a special (season 0) without episode number, the episode is resolved on TMDB
 	Filename      string
 	Extension     string
+	Release       Release        // Quality, source and language tags parsed from the filename
+	Folders       []string       // Parent folders of the file, from the scanned source (excluded) down to its own folder
+	IDs           MediaIDs       // Provider IDs tagged in the names of the file and its folders
+	NFO           string         // Path of the tvshow.nfo sidecar the title and IDs were taken from, if any
+	Subtitles     []SubtitleFile // Sidecar subtitles matched to the file by basename
+	ContentHash   string         // Partial hash of the file content used to detect duplicates, set by HashContent, empty if it could not be read
 }
 
 func (t TVShowFile) String() string {
+	if t.AirDate != "" {
+		return fmt.Sprintf("%-100s --> %s %s", t.Filename, t.SanitizedName, t.AirDate)
+	}
+	if t.Absolute {
+		return fmt.Sprintf("%-100s --> %s #%d", t.Filename, t.SanitizedName, t.Episode)
+	}
+	if t.SpecialName != "" {
+		return fmt.Sprintf("%-100s --> %s Special %s", t.Filename, t.SanitizedName, t.SpecialName)
+	}
+	if t.EpisodeEnd > t.Episode {
+		return fmt.Sprintf("%-100s --> %s S%.2dE%.2d-E%.2d", t.Filename, t.SanitizedName, t.Season, t.Episode, t.EpisodeEnd)
+	}
 	return fmt.Sprintf("%-100s --> %s S%.2dE%.2d", t.Filename, t.SanitizedName, t.Season, t.Episode)
 }
 
-var allowedExtension = []string{
+// MatchHints returns the inputs of the lookup of the file, to tell when they change
+func (t TVShowFile) MatchHints() string {
+	return fmt.Sprintf("%s|%d|%d|%d|%t|%s|%s|%+v", t.SanitizedName, t.Season, t.Episode, t.EpisodeEnd, t.Absolute, t.AirDate, t.SpecialName, t.IDs)
+}
+
+// Episodes returns the numbers of the episodes held by the file
+func (t TVShowFile) Episodes() []int {
+	var episodes = []int{t.Episode}
+	for episode := t.Episode + 1; episode <= t.EpisodeEnd; episode++ {
+		episodes = append(episodes, episode)
+	}
+	return episodes
+}
+
+// DefaultExtensions are the media file extensions accepted when none are configured
+var DefaultExtensions = []string{
 	".mp4",
 	".mkv",
 	".avi",
+	".m4v",
+	".mov",
+	".webm",
+	".ts",
+	".m2ts",
 }
 
-func BuildMovieTree(source string) ([]MovieFile, error) {
-	entries, err := os.ReadDir(source)
+// TreeOptions tells which files of a source folder are media files
+type TreeOptions struct {
+	Extensions        []string          // Accepted media file extensions (e.g. .mkv), matched case-insensitively, DefaultExtensions if empty
+	SniffContent      bool              // Accept files by their detected video container instead of their extension
+	IgnorePatterns    []string          // Gitignore-style patterns relative to the source, completed by the .indexerignore files of the tree
+	SampleMaxSize     int64             // Media files up to this size in bytes are skipped as samples, 0 to disable
+	SampleMaxDuration time.Duration     // Media files up to this duration are skipped as samples, 0 to disable
+	Stability         *StabilityTracker // Tracks the media files still being written across scans, nil to accept every file
+	FollowSymlinks    bool              // Walk the folders behind symbolic links, each folder being walked once to stop loops
+	Workers           int               // Number of folders walked at once, 1 or less to walk them one after another
+}
+
+// BuildMovieTree recursively builds a tree of movie files in the given source directory
+func BuildMovieTree(source string, options TreeOptions) ([]MovieFile, error) {
+	mediaFiles, _, err := BuildMovieSubtree(source, source, options)
+	return mediaFiles, err
+}
+
+// BuildMovieSubtree recursively builds the movie files of a subtree of the source directory,
+// parsing them in the context of the folders between the source and the subtree as BuildMovieTree would.
+// It returns the report of the walk as well, the unreadable subfolders being skipped with a warning.
+func BuildMovieSubtree(source, subtree string, options TreeOptions) ([]MovieFile, WalkReport, error) {
+	start := time.Now()
+	context, err := newSubtreeContext(source, subtree, options)
+	if err != nil {
+		return nil, WalkReport{}, err
+	}
+	if context.ignored {
+		return []MovieFile{}, WalkReport{}, nil
+	}
+	walker := newTreeWalker(options)
+	mediaFiles, err := walker.buildMovieTree(subtree, context.folders, context.rules)
+	if err != nil {
+		return nil, WalkReport{}, err
+	}
+	options.Stability.Sweep(subtree, start)
+	return mediaFiles, walker.finish(start), nil
+}
+
+// buildMovieTree recursively builds the movie files of a folder, the given folders being its parent chain used as parsing context
+// and rules the ignore rules applying to it
+func (w *treeWalker) buildMovieTree(source string, folders []string, rules ignoreRules) ([]MovieFile, error) {
+	var options = w.options
+	entries, err := w.readDir(source)
 	if err != nil {
 		return nil, err
 	}
-	var mediaFiles = make([]MovieFile, 0)
-	for _, entry := range entries {
+	rules = rules.readIgnoreFile(source, entries, folders)
+	// A ripped disc is a single movie, its streams are not walked
+	if name, _ := findDiscFolder(entries); name != "" {
+		return options.buildDiscMovie(source, entries, folders), nil
+	}
+	var videoFilenames = options.findMediaFilenames(source, entries)
+	// The media files are kept in the order of the entries, whichever subfolder is walked first
+	var results = make([][]MovieFile, len(entries))
+	var subfolders = make([]string, 0)
+	var subfolderIndexes = make([]int, 0)
+	for i, entry := range entries {
+		if isIgnored(entry, folders, rules) {
+			continue
+		}
 		if entry.IsDir() {
-			recursiveMediaFiles, err := BuildMovieTree(filepath.Join(source, entry.Name()))
-			if err != nil {
-				return nil, err
-			}
-			mediaFiles = append(mediaFiles, recursiveMediaFiles...)
+			subfolders = append(subfolders, filepath.Join(source, entry.Name()))
+			subfolderIndexes = append(subfolderIndexes, i)
 		} else {
-			if hasAllowedExtension(entry.Name()) {
-				var title, year = SanitizeMovieFilename(entry.Name())
+			if isPartialDownload(entry.Name()) {
+				log.Println("Skipping file still being downloaded: ", entry.Name())
+			} else if isListed(videoFilenames, entry.Name()) {
+				if !options.isStable(source, entries, entry) {
+					continue
+				}
+				if reason, ok := options.isSampleSize(filepath.Join(source, entry.Name())); ok {
+					log.Printf("Ignoring sample %s (%s)", entry.Name(), reason)
+					continue
+				}
+				var title, year = SanitizeMovieFilenameInContext(entry.Name(), folders)
 				mediaFile := MovieFile{
 					Path:          source,
 					Filename:      entry.Name(),
 					SanitizedName: title,
 					Year:          year,
 					Extension:     getExtension(entry.Name()),
+					Release:       ParseRelease(entry.Name()),
+					Folders:       folders,
+					IDs:           FindMediaIDs(entry.Name(), folders),
+					Subtitles:     findSubtitles(source, entries, entry.Name(), videoFilenames),
 				}
-				mediaFiles = append(mediaFiles, mediaFile)
-			} else {
+				if nfo := findMovieNFO(source, entries, entry.Name()); nfo != nil {
+					applyMovieNFO(&mediaFile, nfo)
+				}
+				results[i] = []MovieFile{mediaFile}
+			} else if !hasSubtitleExtension(entry.Name()) {
 				log.Println("Not allowed extension: ", entry.Name())
 			}
 		}
 	}
+	w.walkSubfolders(subfolders, func(i int, subfolder string) error {
+		var entry = entries[subfolderIndexes[i]]
+		recursiveMediaFiles, err := w.buildMovieTree(subfolder, appendFolder(folders, entry.Name()), rules)
+		results[subfolderIndexes[i]] = recursiveMediaFiles
+		return err
+	})
+	var mediaFiles = make([]MovieFile, 0)
+	for _, result := range results {
+		mediaFiles = append(mediaFiles, result...)
+	}
 	return mediaFiles, nil
 }
 
 // BuildTVShowTree recursively builds a tree of TV show files in the given source directory.
-func BuildTVShowTree(source string) ([]TVShowFile, error) {
+// The anime mode tells which filenames are parsed with absolute episode numbers.
+func BuildTVShowTree(source string, animeMode AnimeMode, options TreeOptions) ([]TVShowFile, error) {
+	tvShowFiles, _, err := BuildTVShowSubtree(source, source, animeMode, options)
+	return tvShowFiles, err
+}
+
+// BuildTVShowSubtree recursively builds the TV show files of a subtree of the source directory,
+// parsing them in the context of the folders between the source and the subtree as BuildTVShowTree would.
+// It returns the report of the walk as well, the unreadable subfolders being skipped with a warning.
+func BuildTVShowSubtree(source, subtree string, animeMode AnimeMode, options TreeOptions) ([]TVShowFile, WalkReport, error) {
+	start := time.Now()
+	context, err := newSubtreeContext(source, subtree, options)
+	if err != nil {
+		return nil, WalkReport{}, err
+	}
+	if context.ignored {
+		return []TVShowFile{}, WalkReport{}, nil
+	}
+	walker := newTreeWalker(options)
+	tvShowFiles, err := walker.buildTVShowTree(subtree, animeMode, context.folders, context.rules, context.showNFO)
+	if err != nil {
+		return nil, WalkReport{}, err
+	}
+	options.Stability.Sweep(subtree, start)
+	return tvShowFiles, walker.finish(start), nil
+}
+
+// buildTVShowTree recursively builds the TV show files of a folder, the given folders being its parent chain used as parsing context,
+// rules the ignore rules applying to it and showNFO the nearest tvshow.nfo sidecar found in this chain
+func (w *treeWalker) buildTVShowTree(source string, animeMode AnimeMode, folders []string, rules ignoreRules, showNFO *NFO) ([]TVShowFile, error) {
+	var options = w.options
 	// Read the directory entries from the source directory.
-	entries, err := os.ReadDir(source)
+	entries, err := w.readDir(source)
 	if err != nil {
 		return nil, err
 	}
 
-	// Initialize a slice to store the TV show files.
-	var tvShowFiles = make([]TVShowFile, 0)
+	// An .indexerignore file applies to its folder and subfolders, on top of the rules of the parent folders.
+	rules = rules.readIgnoreFile(source, entries, folders)
+
+	// A ripped disc doesn't tell its episodes apart, discs are only ingested as movies.
+	if name, _ := findDiscFolder(entries); name != "" {
+		log.Println("Skipping disc, discs are only ingested as movies: ", source)
+		return []TVShowFile{}, nil
+	}
+
+	// A tvshow.nfo sidecar applies to every file of its folder and subfolders.
+	if nfo := findTVShowNFO(source, entries); nfo != nil {
+		showNFO = nfo
+	}
+
+	// List the media files first, the sidecar subtitles going with the one with the longest matching name.
+	var videoFilenames = options.findMediaFilenames(source, entries)
+
+	// Initialize a slice per entry to store the TV show files, keeping them in the order of the entries whichever subfolder is walked first.
+	var results = make([][]TVShowFile, len(entries))
+	var subfolders = make([]string, 0)
+	var subfolderIndexes = make([]int, 0)
 
 	// Iterate over the entries in the source directory.
-	for _, entry := range entries {
-		// If the entry is a directory, recurse and add the resulting TV show files to the slice.
+	for i, entry := range entries {
+		// Skip the entries matched by the ignore rules, the samples and the extras.
+		if isIgnored(entry, folders, rules) {
+			continue
+		}
+		// If the entry is a directory, walk it with the other subfolders once the files are done.
 		if entry.IsDir() {
-			recursiveTVShowFiles, err := BuildTVShowTree(filepath.Join(source, entry.Name()))
-			if err != nil {
-				return nil, err
-			}
-			tvShowFiles = append(tvShowFiles, recursiveTVShowFiles...)
+			subfolders = append(subfolders, filepath.Join(source, entry.Name()))
+			subfolderIndexes = append(subfolderIndexes, i)
 		} else {
 			// If the entry has an allowed extension, extract the TV show file information and add it to the slice.
-			if hasAllowedExtension(entry.Name()) {
-				var title, season, episode = SanitizeTVShowFilename(entry.Name())
+			if isPartialDownload(entry.Name()) {
+				log.Println("Skipping file still being downloaded: ", entry.Name())
+			} else if isListed(videoFilenames, entry.Name()) {
+				if !options.isStable(source, entries, entry) {
+					continue
+				}
+				if reason, ok := options.isSampleSize(filepath.Join(source, entry.Name())); ok {
+					log.Printf("Ignoring sample %s (%s)", entry.Name(), reason)
+					continue
+				}
+				var title, season, episode, episodeEnd = SanitizeTVShowFilenameInContext(entry.Name(), folders)
 				tvShowFile := TVShowFile{
 					Path:          source,
 					SanitizedName: title,
 					Season:        season,
 					Episode:       episode,
+					EpisodeEnd:    episodeEnd,
 					Filename:      entry.Name(),
 					Extension:     getExtension(entry.Name()),
+					Release:       ParseRelease(entry.Name()),
+					Folders:       folders,
+					IDs:           FindMediaIDs(entry.Name(), folders),
+					Subtitles:     findSubtitles(source, entries, entry.Name(), videoFilenames),
 				}
-				tvShowFiles = append(tvShowFiles, tvShowFile)
-			} else {
+				if title, airDate, ok := SanitizeDailyFilename(entry.Name()); ok {
+					tvShowFile.SanitizedName = title
+					tvShowFile.Season = 0
+					tvShowFile.Episode = 0
+					tvShowFile.EpisodeEnd = 0
+					tvShowFile.AirDate = airDate
+				} else if title, episode, specialName, ok := SanitizeSpecialFilename(entry.Name()); ok {
+					tvShowFile.SanitizedName = title
+					tvShowFile.Season = 0
+					tvShowFile.Episode = episode
+					tvShowFile.EpisodeEnd = episode
+					tvShowFile.SpecialName = specialName
+				} else if title, episode, episodeEnd, ok := SanitizeAnimeFilename(entry.Name(), animeMode); ok {
+					tvShowFile.SanitizedName = title
+					tvShowFile.Season = 0
+					tvShowFile.Episode = episode
+					tvShowFile.EpisodeEnd = episodeEnd
+					tvShowFile.Absolute = true
+				}
+				if showNFO != nil {
+					applyTVShowNFO(&tvShowFile, showNFO)
+				}
+				results[i] = []TVShowFile{tvShowFile}
+			} else if !hasSubtitleExtension(entry.Name()) {
 				log.Println("Not allowed extension: ", entry.Name())
 			}
 		}
 	}
 
+	// Walk the subfolders, several at once while workers are available.
+	w.walkSubfolders(subfolders, func(i int, subfolder string) error {
+		var entry = entries[subfolderIndexes[i]]
+		recursiveTVShowFiles, err := w.buildTVShowTree(subfolder, animeMode, appendFolder(folders, entry.Name()), rules, showNFO)
+		results[subfolderIndexes[i]] = recursiveTVShowFiles
+		return err
+	})
+
+	var tvShowFiles = make([]TVShowFile, 0)
+	for _, result := range results {
+		tvShowFiles = append(tvShowFiles, result...)
+	}
 	return tvShowFiles, nil
 }
 
+// buildDiscMovie builds the movie of a disc folder, named after the folder or its parents if its name is generic (e.g. "Disc 1").
+// The given folders end with the disc folder itself.
+func (o TreeOptions) buildDiscMovie(source string, entries []os.DirEntry, folders []string) []MovieFile {
+	if len(folders) == 0 {
+		log.Println("Skipping disc at the root of the source, its folder name is needed to match it: ", source)
+		return []MovieFile{}
+	}
+	disc, err := ReadDisc(source)
+	if err != nil {
+		log.Printf("Skipping disc %s: %v", source, err)
+		return []MovieFile{}
+	}
+	for _, file := range disc.Files {
+		info, err := os.Stat(file)
+		if err != nil || !o.Stability.IsStable(file, info) {
+			log.Println("Skipping disc still being written: ", source)
+			return []MovieFile{}
+		}
+	}
+	var name = folders[len(folders)-1]
+	var parents = folders[:len(folders)-1]
+	var title, year = SanitizeMovieFolderInContext(name, parents)
+	mediaFile := MovieFile{
+		Path:          filepath.Dir(source),
+		Filename:      name,
+		SanitizedName: title,
+		Year:          year,
+		Release:       ParseReleaseFolder(name),
+		Folders:       parents,
+		IDs:           FindMediaIDs(name, parents),
+		Disc:          disc,
+	}
+	// A full disc is untouched, unlike the encodes tagged BluRay or DVD
+	if mediaFile.Release.Source == "" && disc.Type == DiscBluRay {
+		mediaFile.Release.Source = "Remux"
+	} else if mediaFile.Release.Source == "" {
+		mediaFile.Release.Source = "DVD"
+	}
+	var nfo = readNFO(source, entries, name+".nfo")
+	if nfo == nil {
+		nfo = readNFO(source, entries, movieNFOFilename)
+	}
+	if nfo != nil {
+		applyMovieNFO(&mediaFile, nfo)
+	}
+	log.Printf("Found %s disc %s, main feature of %s in %d files", disc.Type, source, disc.Duration.Round(time.Second), len(disc.Files))
+	return []MovieFile{mediaFile}
+}
+
+// applyMovieNFO replaces the title, year and IDs parsed from the filename with the ones of the NFO sidecar
+func applyMovieNFO(mediaFile *MovieFile, nfo *NFO) {
+	if nfo.Title != "" {
+		mediaFile.SanitizedName = nfo.Title
+		mediaFile.Year = nfo.Year
+	}
+	mediaFile.IDs = nfo.IDs.Complete(mediaFile.IDs)
+	mediaFile.NFO = nfo.Path
+}
+
+// applyTVShowNFO replaces the show title and IDs parsed from the filename with the ones of the tvshow.nfo sidecar,
+// the season and episode numbers still come from the filename
+func applyTVShowNFO(tvShowFile *TVShowFile, nfo *NFO) {
+	if nfo.Title != "" {
+		tvShowFile.SanitizedName = nfo.Title
+	}
+	tvShowFile.IDs = nfo.IDs.Complete(tvShowFile.IDs)
+	tvShowFile.NFO = nfo.Path
+}
+
+// subtreeContext holds what the folders between a source and one of its subtrees bring to the parsing of the subtree files
+type subtreeContext struct {
+	folders []string    // Folders from the source (excluded) down to the subtree
+	rules   ignoreRules // Ignore rules of the source and of the folders above the subtree
+	showNFO *NFO        // Nearest tvshow.nfo sidecar above the subtree
+	ignored bool        // The subtree itself is skipped by the ignore rules or as a samples or extras folder
+}
+
+// newSubtreeContext reads the folders from the source down to the subtree, as the tree walk would before reaching the subtree
+func newSubtreeContext(source, subtree string, options TreeOptions) (subtreeContext, error) {
+	var context = subtreeContext{
+		folders: []string{},
+		rules:   parseIgnorePatterns(options.IgnorePatterns, ""),
+	}
+	if !isInFolder(subtree, source) {
+		return context, fmt.Errorf("%s is not inside %s", subtree, source)
+	}
+	relativePath, err := filepath.Rel(source, subtree)
+	if err != nil || relativePath == "." {
+		return context, err
+	}
+	var folder = source
+	for _, name := range strings.Split(relativePath, string(filepath.Separator)) {
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			return context, err
+		}
+		context.rules = context.rules.readIgnoreFile(folder, entries, context.folders)
+		if nfo := findTVShowNFO(folder, entries); nfo != nil {
+			context.showNFO = nfo
+		}
+		var found = false
+		for _, entry := range entries {
+			if entry.Name() != name || !entry.IsDir() {
+				continue
+			}
+			found = true
+			if isIgnored(entry, context.folders, context.rules) {
+				context.ignored = true
+				return context, nil
+			}
+		}
+		if !found {
+			return context, fmt.Errorf("folder %s not found in %s", name, folder)
+		}
+		context.folders = appendFolder(context.folders, name)
+		folder = filepath.Join(folder, name)
+	}
+	return context, nil
+}
+
+// appendFolder returns a copy of the parent chain with the given folder appended, so that sibling folders don't share it
+func appendFolder(folders []string, folder string) []string {
+	var result = make([]string, len(folders), len(folders)+1)
+	copy(result, folders)
+	return append(result, folder)
+}
+
 func getExtension(name string) string {
-	return name[strings.LastIndex(name, "."):]
+	return filepath.Ext(name)
 }
 
-func hasAllowedExtension(filename string) bool {
-	for _, extension := range allowedExtension {
-		if strings.HasSuffix(filename, extension) {
+// findMediaFilenames returns the names of the media files among the entries of a folder, the partial downloads excluded
+func (o TreeOptions) findMediaFilenames(folder string, entries []os.DirEntry) []string {
+	var filenames = make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() && !isPartialDownload(entry.Name()) && o.isMediaFile(folder, entry.Name()) {
+			filenames = append(filenames, entry.Name())
+		}
+	}
+	return filenames
+}
+
+func isListed(filenames []string, filename string) bool {
+	for _, listed := range filenames {
+		if listed == filename {
 			return true
 		}
 	}
 	return false
 }
+
+// isMediaFile tells whether a file is a media file, by its extension or its content if sniffing is enabled
+func (o TreeOptions) isMediaFile(folder, filename string) bool {
+	if o.SniffContent {
+		return isVideoContainer(filepath.Join(folder, filename))
+	}
+	var extensions = o.Extensions
+	if len(extensions) == 0 {
+		extensions = DefaultExtensions
+	}
+	for _, extension := range extensions {
+		if strings.EqualFold(filepath.Ext(filename), extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// isVideoContainer detects the container of a file from its content, logging instead of failing since an unreadable file is skipped anyway
+func isVideoContainer(filePath string) bool {
+	mimetype, err := mimetype2.DetectFile(filePath)
+	if err != nil {
+		log.Printf("Failed to detect the type of %s: %v", filePath, err)
+		return false
+	}
+	return strings.HasPrefix(mimetype.String(), "video/")
+}
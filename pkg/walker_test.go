@@ -0,0 +1,37 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTreeWalkerReadDir checks that the walk report counts the files of a folder apart from its subfolders
+func TestTreeWalkerReadDir(t *testing.T) {
+	var folder = t.TempDir()
+	for _, subfolder := range []string{"Season 1", "Extras"} {
+		if err := os.Mkdir(filepath.Join(folder, subfolder), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, filename := range []string{"movie.mkv", "movie.fr.srt", "movie.nfo"} {
+		if err := os.WriteFile(filepath.Join(folder, filename), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(folder, "movie.mkv"), filepath.Join(folder, "link.mkv")); err != nil {
+		t.Fatal(err)
+	}
+
+	walker := newTreeWalker(TreeOptions{})
+	entries, err := walker.readDir(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Errorf("readDir returned %d entries, want 6", len(entries))
+	}
+	if walker.report.Folders != 1 || walker.report.Files != 4 {
+		t.Errorf("report = %d folders and %d files, want 1 folder and 4 files", walker.report.Folders, walker.report.Files)
+	}
+}